@@ -9,13 +9,14 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
 type post string
 
 func (p *post) UnmarshalJSON(b []byte) error {
-	if err := json.Unmarshal(b, &p); err == nil {
+	if err := json.Unmarshal(b, (*string)(p)); err == nil {
 		return nil
 	}
 
@@ -44,8 +45,12 @@ func (p post) execute() error {
 	}
 
 	cmd := exec.Command(string(p))
+	debug("running post %s", cmd)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
+	if plumbing() {
+		cmd.Stdout = os.Stderr
+	}
 
 	return cmd.Run()
 }
@@ -106,67 +111,31 @@ func (a arch) supported() bool {
 
 var wrongOs error = fmt.Errorf("Mismached os")
 
-func (f file) download() error {
-	if !f.Arch.supported() {
-		return wrongOs
-	}
-
-	content, err := get(f.Url)
-	if err != nil {
-		return err
+// destination is where the file is saved, relative to the lite-xl config
+// folder or, when no path is given, on the folder of its addon type
+func (f file) destination(t addonsType) (string, error) {
+	rel := filepath.FromSlash(f.Path)
+	if rel == "" {
+		rel = filepath.Join(t.folder(), path.Base(f.Url))
 	}
 
-	local := f.Path
-	if local == "" {
-		local = path.Base(f.Url)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("Invalid path %s, files must be saved inside the lite-xl config folder", rel)
 	}
-
-	err = os.WriteFile(local, content, 0666)
-	return err
+	return configPath(rel)
 }
 
-type addonsType uint8
-
-const (
-	plugin addonsType = iota
-	font
-	library
-	color
-	meta
-)
-
-var aTypes = []string{"plugin", "font", "library", "color", "meta"}
-
-func (t addonsType) String() string {
-	return aTypes[t]
-}
-
-func (t *addonsType) UnmarshalJSON(b []byte) error {
-	var s string
-	if err := json.Unmarshal(b, &s); err != nil {
-		return err
-	}
-
-	for i := range aTypes {
-		if s == aTypes[i] {
-			*t = addonsType(i)
-		}
-	}
-
-	if t == nil {
-		return fmt.Errorf("Unrecognized addon type: %s", s)
+func (f file) download(local string) error {
+	if !f.Arch.supported() {
+		return wrongOs
 	}
-	return nil
-}
 
-func (t addonsType) folder() string {
-	switch t {
-	case color, font, plugin:
-		return t.String() + "s"
-	case library:
-		return "libraries"
+	content, err := get(f.Url)
+	if err != nil {
+		return err
 	}
-	return plugin.folder()
+	debug("saving %s into %s", f.Url, local)
+	return os.WriteFile(local, content, 0666)
 }
 
 type addon struct {
@@ -190,11 +159,19 @@ type addon struct {
 	Extra        map[string]string      `json:"extra,omitempty"`
 	Files        []file                 `json:"files,omitempty"`
 	repo         string
+	// source is the git repository or the local path of the addons not coming from a remote
+	source string
+	// link is the working copy a linked addon points to
+	link string
 }
 
 func (a addon) dir(subdir ...string) (string, error) {
+	// A single file is always installed on its type folder, wherever it is on its remote
 	var path = a.Path
-	if path == "" && len(a.Files) == 1 && a.Files[0].Path != "" {
+	if a.AddonsType.singleton(path) {
+		path = ""
+	}
+	if path == "" && len(a.Files) == 1 && filepath.IsLocal(filepath.FromSlash(a.Files[0].Path)) {
 		path = a.Files[0].Path
 	}
 
@@ -212,10 +189,14 @@ func (a addon) endpoint() (endpoint string, singleton bool, err error) {
 	} else if a.Remote == "" {
 		switch len(a.Files) {
 		case 0:
+			rel := path.Join(a.AddonsType.folder(), a.ID+".lua")
+			if a.AddonsType.singleton(a.Path) {
+				rel = a.Path
+			}
 			u, err := url.Parse(a.repo)
 			if err == nil {
 				u.Path = path.Dir(u.Path)
-				endpoint, err = url.JoinPath(u.String(), a.AddonsType.folder(), a.ID+".lua")
+				endpoint, err = url.JoinPath(u.String(), rel)
 			}
 		case 1:
 			endpoint = a.Files[0].Url
@@ -232,7 +213,7 @@ func (a addon) endpoint() (endpoint string, singleton bool, err error) {
 		}
 	}
 
-	singleton = strings.HasSuffix(endpoint, ".lua")
+	singleton = a.AddonsType.singleton(endpoint)
 
 	return
 }
@@ -251,47 +232,109 @@ func (a addon) supported() (supported bool) {
 	return
 }
 
-func (a addon) install() error {
-	if !a.supported() {
-		return fmt.Errorf("plugin does not support your OS")
+// within is the path of the addon inside a checkout of its repository, the
+// whole repository when it's not there
+func (a addon) within(checkout string) string {
+	if a.Path == "" || a.Path == "." {
+		return checkout
 	}
 
-	var repo, singleton, err = a.endpoint()
-	if err != nil {
-		return err
+	path := filepath.Join(checkout, filepath.FromSlash(a.Path))
+	if _, err := os.Stat(path); err != nil {
+		return checkout
 	}
+	return path
+}
+
+// staged is an addon downloaded on a temporary folder, it's moved in place
+// only once the addons it replaces have been removed
+type staged struct {
+	addon
+	temp  string            // folder holding everything downloaded
+	from  string            // content of the addon inside temp
+	local string            // where the content is installed
+	files map[string]string // extra files inside temp by their destination
+	// commit checked out when coming from a git repository
+	commit string
+}
 
-	local, err := a.dir()
+// fetch downloads the addon without touching the installed ones
+func (a addon) fetch() (s staged, err error) {
+	if !a.AddonsType.known() {
+		return s, failure(errManifest, "%s has type %s that is not supported, lxl might need an update", a.ID, a.AddonsType)
+	} else if !a.supported() {
+		return s, failure(errForbidden, "plugin does not support your OS")
+	} else if err = a.allowed(); err != nil {
+		return
+	}
+
+	// Meta addons have no file, they only group their dependencies
+	s.addon = a
+	if a.AddonsType == meta {
+		return
+	}
+
+	repo, singleton, err := a.endpoint()
 	if err != nil {
-		return err
+		return
 	}
+	debug("downloading %s from %s (singleton: %t)", a.ID, repo, singleton)
 
-	if singleton {
-		if !strings.HasSuffix(local, ".lua") {
-			local += ".lua"
+	if s.local, err = a.dir(); err != nil {
+		return
+	} else if s.temp, err = os.MkdirTemp("", TEMP_PREFIX+a.ID); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			remove(s.temp)
 		}
+	}()
+	s.from = filepath.Join(s.temp, a.ID)
 
-		content, err := get(repo)
-		if err == nil {
-			err = os.WriteFile(local, content, 0666)
+	switch {
+	case singleton:
+		if ext := path.Ext(repo); !strings.HasSuffix(s.local, ext) {
+			s.local += ext
 		}
-		return err
-	}
 
-	switch a.Path {
-	case ".", filepath.Join(a.AddonsType.folder(), a.ID):
-		_, err = clone(repo, local)
+		var content []byte
+		if content, err = read(repo); err == nil {
+			err = os.WriteFile(s.from, content, 0666)
+		}
+	case a.Path == ".", a.Path == filepath.Join(a.AddonsType.folder(), a.ID):
+		cloned := filepath.Join(s.temp, "repository")
+		if _, err = clone(repo, cloned); err != nil {
+			break
+		} else if s.commit, err = headCommit(cloned); err == nil {
+			err = os.Rename(a.within(cloned), s.from)
+		}
 	default:
-		path, e := clone(repo, "")
+		tree, e := checkout(repo)
 		if e != nil {
-			return e
+			return s, e
+		}
+		defer remove(tree)
+		if isLink(repo) {
+			if s.commit, err = headCommit(tree); err != nil {
+				return
+			}
+		}
+
+		// Files taken from a repository are singletons
+		path := a.within(tree)
+		if info, e := os.Stat(path); e == nil && !info.IsDir() {
+			if ext := filepath.Ext(path); !strings.HasSuffix(s.local, ext) {
+				s.local += ext
+			}
+			err = os.Rename(path, s.from)
+			break
 		}
-		defer remove(path)
 
 		// Detecting singleton
 		entries, e := os.ReadDir(path)
 		if e != nil {
-			return e
+			return s, e
 		}
 		var init *string
 		for _, item := range entries {
@@ -311,30 +354,142 @@ func (a addon) install() error {
 			}
 		}
 		// Singleton detected
-		if init != nil {
-			local += ".lua"
-			err = os.Rename(filepath.Join(path, *init), local)
+		if init != nil && a.AddonsType.singleton(*init) {
+			debug("singleton %s detected on %s", *init, repo)
+			s.local += filepath.Ext(*init)
+			err = os.Rename(filepath.Join(path, *init), s.from)
 			break
 		}
 
-		err = moveDirFiltered(path, local, func(_ string, d os.DirEntry) bool {
+		err = moveDirFiltered(path, s.from, func(_ string, d os.DirEntry) bool {
 			return isRelevant(d)
 		})
+	}
+	if err != nil {
+		return
+	}
+
+	s.files = make(map[string]string, len(a.Files))
+	for i, f := range a.Files {
+		temp := filepath.Join(s.temp, strconv.Itoa(i)+"-"+path.Base(f.Url))
+		dest, e := f.destination(a.AddonsType)
+		if e == nil {
+			e = f.download(temp)
+		}
+		if e == nil {
+			s.files[dest] = temp
+		} else if e != wrongOs && !f.Optional {
+			return s, e
+		}
+	}
+	return
+}
+
+// artifacts maps every file that will be installed to its checksum
+func (s staged) artifacts() (map[string]string, error) {
+	artifacts := make(map[string]string)
+	if s.from == "" {
+		return artifacts, nil
+	}
+
+	found, err := fingerprint(s.from)
+	if err != nil {
+		return nil, err
+	}
+	for path, sum := range found {
+		rel, _ := filepath.Rel(s.from, path)
+		artifacts[filepath.Join(s.local, rel)] = sum
+	}
+	for dest, path := range s.files {
+		if artifacts[dest], err = checksum(path); err != nil {
+			return nil, err
+		}
+	}
+	return artifacts, nil
+}
+
+// place moves the downloaded addon in place and runs its post command
+func (s staged) place() (err error) {
+	defer remove(s.temp)
+	if s.from == "" {
+		return updateStatus(func(l *lxl) error {
+			l.track(s.addon, nil)
+			return nil
+		})
+	}
 
+	// Nothing is left behind when the addon cannot be completely installed
+	placed := []string{s.local}
+	defer func() {
 		if err != nil {
-			remove(local)
+			for _, path := range placed {
+				remove(path)
+			}
 		}
+	}()
+
+	debug("installing %s on %s", s.ID, s.local)
+	if err = os.MkdirAll(filepath.Dir(s.local), 0750); err != nil {
+		return
+	} else if info, e := os.Stat(s.from); e == nil && info.IsDir() {
+		err = moveDir(s.from, s.local, 0750)
+	} else {
+		err = os.Rename(s.from, s.local)
+	}
+	if err != nil {
+		return
 	}
 
+	// Keeping track of every artifact to remove them when uninstalling
+	artifacts, err := fingerprint(s.local)
 	if err != nil {
-		return err
+		return
 	}
 
-	for _, f := range a.Files {
-		if err = f.download(); err != nil && err != wrongOs && !f.Optional {
-			return err
+	for dest, path := range s.files {
+		trace("moving %s to %s", path, dest)
+		if err = os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+			return
+		} else if err = os.Rename(path, dest); err != nil {
+			return
+		}
+		placed = append(placed, dest)
+		if artifacts[dest], err = checksum(dest); err != nil {
+			return
+		}
+	}
+
+	if s.Post != "" {
+		root, e := configPath()
+		if e != nil {
+			return e
+		}
+
+		before, e := snapshot(root)
+		if e != nil {
+			return e
+		}
+		if err = s.Post.execute(); err != nil {
+			return
+		}
+		after, e := snapshot(root)
+		if e != nil {
+			return e
+		}
+
+		for path := range after {
+			if _, ok := before[path]; !ok {
+				trace("%s created by post command of %s", path, s.ID)
+				artifacts[path] = after[path]
+			}
 		}
 	}
 
-	return a.Post.execute()
+	return updateStatus(func(l *lxl) error {
+		l.track(s.addon, artifacts)
+		r := l.Installed[s.ID]
+		r.Commit = s.commit
+		l.Installed[s.ID] = r
+		return nil
+	})
 }
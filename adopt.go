@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var adoptByID bool
+
+// owner finds the installed addon that the saved one belongs to
+func (l *lxl) owner(a addon) (string, bool) {
+	for id, r := range l.Installed {
+		if len(r.Files) == 0 && id == a.ID {
+			return id, true
+		}
+		for f := range r.Files {
+			if f == a.Path || strings.HasPrefix(f, a.Path+string(filepath.Separator)) {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// match looks on the manifest for the addon having the same content of the saved one
+func (m *manifest) match(a addon) *addon {
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return nil
+	} else if info.IsDir() {
+		if adoptByID {
+			return m.find(a.ID)
+		}
+		debug("cannot verify folder %s, use --by-id to adopt it anyway", a.Path)
+		return nil
+	}
+
+	sum, err := checksum(a.Path)
+	if err != nil {
+		return nil
+	}
+
+	for i := range m.Addons {
+		if strings.EqualFold(m.Addons[i].Checksum, sum) {
+			return &m.Addons[i]
+		}
+	}
+
+	// Comparing with the remote content of the addon having the same ID
+	item := m.find(a.ID)
+	if item == nil {
+		return nil
+	} else if endpoint, singleton, err := item.endpoint(); err == nil && singleton {
+		if content, err := get(endpoint); err == nil {
+			remote := sha256.Sum256(content)
+			if hex.EncodeToString(remote[:]) == sum {
+				return item
+			}
+		}
+	}
+
+	if adoptByID {
+		return item
+	}
+	return nil
+}
+
+func adopt() (err error) {
+	manifest, err := fetchManifest()
+	if err != nil {
+		return
+	}
+
+	var untracked []addon
+	err = updateStatus(func(l *lxl) error {
+		return rangeSaved(func(a addon) error {
+			if _, ok := l.owner(a); ok {
+				return nil
+			}
+
+			found := manifest.match(a)
+			if found == nil {
+				untracked = append(untracked, a)
+				return nil
+			} else if _, ok := l.installed(found.ID); ok {
+				debug("%s matches %s that is already installed", a.Path, found.ID)
+				untracked = append(untracked, a)
+				return nil
+			}
+
+			files, err := fingerprint(a.Path)
+			if err != nil {
+				return err
+			}
+			debug("%s matches %s v%s from %s", a.Path, found.ID, found.Version, found.repo)
+			l.track(*found, files)
+			result.Adopted = append(result.Adopted, found.ID)
+			return nil
+		})
+	})
+	if err != nil {
+		return
+	}
+
+	switch n := len(result.Adopted); n {
+	case 0:
+		success(current.name, "No addon to adopt")
+	case 1:
+		success(current.name, "Adopted "+result.Adopted[0])
+	default:
+		success(current.name, "Adopted "+strconv.Itoa(n)+" addons: "+strings.Join(result.Adopted, ", "))
+	}
+	showUntracked(untracked)
+	return skip
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MANIFEST_FILE is the manifest written by init and manifest add
+const MANIFEST_FILE = "manifest.json"
+
+var (
+	manifestPath string
+	addonVersion string
+)
+
+var modVersionRgx = regexp.MustCompile(`^--\s*mod[-_]version\s*:\s*(\d+(?:\.\d+)*)`)
+
+// headerModVersion reads the mod-version declared on the first line of a lua
+// file, folders declare it on their init.lua
+func headerModVersion(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "init.lua")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	if m := modVersionRgx.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// typeFolder finds the type owning the first folder of a relative path
+func typeFolder(rel string) (addonsType, bool) {
+	dir, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	for _, t := range installable() {
+		if t.folder() == dir {
+			return t, true
+		}
+	}
+	return plugin, false
+}
+
+// gitRemote is the link of the origin of the repository at its current commit
+func gitRemote(dir string) (string, error) {
+	origin, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", err
+	}
+	commit, err := headCommit(dir)
+	if err != nil {
+		return "", err
+	}
+
+	link := strings.TrimSuffix(strings.TrimSpace(string(origin)), ".git")
+	if !isLink(link) {
+		return "", failure(errArgs, "Unsupported origin %s", link)
+	}
+	return link + ":" + commit, nil
+}
+
+// gitChanged checks if the content at rel differs between the commits of two
+// links to the repository at root
+func gitChanged(root, rel, from, to string) bool {
+	tree := func(link string) string {
+		_, _, commit, err := extract(link)
+		if err != nil || commit == "" {
+			return ""
+		}
+		out, err := exec.Command("git", "-C", root, "rev-parse", commit+":"+strings.TrimPrefix(rel, ".")).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	old := tree(from)
+	return old == "" || old != tree(to)
+}
+
+// describe builds the manifest entry of the addon at path, root is the folder
+// of the manifest. Files are downloaded from their path next to the manifest
+// while folders are taken from the repository at its current commit
+func describe(root, path string) (a addon, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return a, failure(errNotFound, "Cannot find %s", path)
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return a, failure(errArgs, "%s is outside of %s", path, root)
+	}
+
+	name := info.Name()
+	if rel == "." {
+		abs, _ := filepath.Abs(root)
+		name = filepath.Base(abs)
+	}
+
+	// The folder wins over the name unless they agree on where to install it
+	a.ID, a.AddonsType = idOf(name), inferType(name)
+	if t, ok := typeFolder(rel); ok && t.folder() != a.AddonsType.folder() {
+		a.AddonsType = t
+	}
+	if sourceType != "" {
+		a.AddonsType = registerType(sourceType)
+	}
+	a.Version, a.ModVersion = "0.1", headerModVersion(path)
+
+	if !info.IsDir() {
+		if want := filepath.Join(a.AddonsType.folder(), a.ID+filepath.Ext(name)); rel != want {
+			warn("Unexpected location", rel+" is installed on "+filepath.ToSlash(want)+", move it there")
+		}
+		a.Path = filepath.ToSlash(rel)
+		a.Checksum, err = checksum(path)
+		return
+	}
+
+	if want := filepath.Join(a.AddonsType.folder(), a.ID); rel != "." && rel != want {
+		warn("Unexpected location", rel+" is installed on "+filepath.ToSlash(want)+", move it there")
+	}
+	a.Path = filepath.ToSlash(rel)
+	if a.Remote, err = gitRemote(root); err != nil {
+		debug("cannot detect the remote of %s: %s", root, err)
+		warn("Missing remote", "set the remote of "+a.ID+" to the link of its repository")
+		a.Remote, err = "", nil
+	}
+	return
+}
+
+// locate finds where an addon of the manifest is on its repository
+func (a addon) locate(root string) (string, bool) {
+	folder := a.AddonsType.folder()
+	candidates := []string{filepath.Join(folder, a.ID)}
+	if a.Path != "" {
+		candidates = append([]string{filepath.FromSlash(a.Path)}, candidates...)
+	}
+	for _, ext := range a.AddonsType.info().Extensions {
+		candidates = append(candidates, filepath.Join(folder, a.ID+ext))
+	}
+
+	for _, c := range candidates {
+		if path := filepath.Join(root, c); c != "" {
+			if _, err := os.Stat(path); err == nil {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// bump increments the last part of a version
+func bump(raw string) string {
+	v, err := parseVersion(raw)
+	if err != nil || len(v) == 0 {
+		return raw
+	}
+	v[len(v)-1]++
+	return v.String()
+}
+
+func readManifest(path string) (m manifest, err error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return m, failure(errFilesystem, "Cannot read %s: %s", path, err)
+	}
+
+	if err = json.Unmarshal(raw, &m); err != nil {
+		return m, failure(errManifest, "Error while parsing %s: %s", path, err)
+	}
+	return m, m.defineTypes(path)
+}
+
+// writeManifest saves the manifest failing when it's not valid
+func writeManifest(path string, m manifest) error {
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	if err = os.WriteFile(path, raw, 0666); err != nil {
+		return failure(errFilesystem, "Cannot write %s: %s", path, err)
+	}
+
+	issues := validate(path, raw)
+	showIssues(issues)
+	if slices.ContainsFunc(issues, func(i issue) bool { return i.Severity == errorSeverity }) {
+		return failure(errManifest, "%s is not valid, fix the errors above", path)
+	}
+	return nil
+}
+
+// initManifest scaffolds the manifest of a repository with the addons found
+// on the type folders or, when there are none, with the repository itself
+func initManifest(dir string) (err error) {
+	path := filepath.Join(dir, MANIFEST_FILE)
+	if _, e := os.Stat(path); e == nil {
+		return failure(errForbidden, "%s already exists, use \"lxl manifest add\" to add addons to it", path)
+	}
+
+	var m manifest
+	for _, t := range installable() {
+		entries, e := os.ReadDir(filepath.Join(dir, t.folder()))
+		if e != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !isRelevant(entry) {
+				continue
+			}
+			a, e := describe(dir, filepath.Join(dir, t.folder(), entry.Name()))
+			if e != nil {
+				return e
+			}
+			debug("found %s %s on %s", a.AddonsType, a.ID, a.Path)
+			m.Addons = append(m.Addons, a)
+		}
+	}
+
+	if len(m.Addons) == 0 {
+		a, e := describe(dir, dir)
+		if e != nil {
+			return e
+		}
+		m.Addons = append(m.Addons, a)
+	}
+
+	if err = writeManifest(path, m); err != nil {
+		return
+	}
+	success(current.name, "Created "+path+" with "+strconv.Itoa(len(m.Addons))+" addons")
+	return skip
+}
+
+// update describes the addon at path on the manifest, an addon already
+// present keeps what cannot be detected and gets a new version when changed.
+// The ID and type of a known addon are kept as they are
+func (m *manifest) update(path string, known *addon) (id string, added bool, err error) {
+	root := filepath.Dir(manifestPath)
+	a, err := describe(root, path)
+	if err != nil {
+		return
+	} else if known != nil {
+		a.ID, a.AddonsType = known.ID, known.AddonsType
+	}
+
+	i := slices.IndexFunc(m.Addons, func(item addon) bool { return item.ID == a.ID })
+	if i < 0 {
+		if addonVersion != "" {
+			a.Version = addonVersion
+		}
+		m.Addons = append(m.Addons, a)
+		return a.ID, true, nil
+	}
+
+	// A changed content is a new version
+	old := &m.Addons[i]
+	switch {
+	case addonVersion != "":
+		old.Version = addonVersion
+	case a.Checksum != "" && old.Checksum != "" && a.Checksum != old.Checksum:
+		old.Version = bump(old.Version)
+	case a.Remote != "" && old.Remote != "" && a.Remote != old.Remote && gitChanged(root, a.Path, old.Remote, a.Remote):
+		old.Version = bump(old.Version)
+	}
+	old.Path, old.Checksum = a.Path, a.Checksum
+	if a.ModVersion != "" {
+		old.ModVersion = a.ModVersion
+	}
+	if a.Remote != "" {
+		old.Remote = a.Remote
+	}
+	debug("%s is now v%s", old.ID, old.Version)
+	return old.ID, false, nil
+}
+
+// manifestAdd adds the addons at the given paths to the manifest
+func manifestAdd(paths []string) (err error) {
+	m, err := readManifest(manifestPath)
+	if err != nil {
+		return
+	}
+
+	var added, updated []string
+	for _, path := range paths {
+		id, isNew, e := m.update(path, nil)
+		if e != nil {
+			return e
+		} else if isNew {
+			added = append(added, id)
+		} else {
+			updated = append(updated, id)
+		}
+	}
+
+	if err = writeManifest(manifestPath, m); err != nil {
+		return
+	}
+	if len(added) > 0 {
+		success(current.name, "Added "+strings.Join(added, ", ")+" to "+manifestPath)
+	}
+	if len(updated) > 0 {
+		success(current.name, "Updated "+strings.Join(updated, ", ")+" on "+manifestPath)
+	}
+	return skip
+}
+
+// publish updates every addon of the manifest with the current content of
+// the repository so the manifest is ready to be committed and pushed
+func publish() (err error) {
+	m, err := readManifest(manifestPath)
+	if err != nil {
+		return
+	} else if len(m.Addons) == 0 {
+		return failure(errNotFound, "No addons on %s, use \"lxl init\" or \"lxl manifest add\" first", manifestPath)
+	}
+
+	root := filepath.Dir(manifestPath)
+	for _, a := range m.Addons {
+		// Addons hosted elsewhere are left to their authors
+		if a.AddonsType == meta || a.Url != "" || len(a.Files) > 0 && a.Path == "" {
+			continue
+		}
+
+		path, ok := a.locate(root)
+		if !ok {
+			warn("Cannot find "+a.ID, "it's kept as it is on "+manifestPath)
+			continue
+		} else if _, _, err = m.update(path, &a); err != nil {
+			return
+		}
+	}
+
+	if err = writeManifest(manifestPath, m); err != nil {
+		return
+	}
+
+	// Folders are published at the current commit
+	status := exec.Command("git", "-C", root, "status", "--porcelain", "--", ".", ":(exclude)"+filepath.Base(manifestPath))
+	if out, e := status.Output(); e == nil && len(out) > 0 {
+		warn("Uncommitted changes", "the folder addons are published as they are on the last commit")
+	}
+	success(current.name, "Updated "+strconv.Itoa(len(m.Addons))+" addons on "+manifestPath+", commit and push it to publish them")
+	return skip
+}
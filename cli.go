@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type subcommand struct {
+	name     string
+	args     string
+	summary  string
+	min      int
+	max      int // negative for unlimited arguments
+	hidden   bool
+	flags    func(*flag.FlagSet)
+	complete func() ([]string, error)
+	run      func(args []string) error
+}
+
+var current *subcommand
+
+func lookup(name string) *subcommand {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func globalFlags(fs *flag.FlagSet) {
+	fs.Func("format", "output `format`: text, json or tsv", func(s string) (err error) {
+		format, err = parseFormat(s)
+		return
+	})
+	fs.BoolFunc("json", "same as --format=json", func(string) error {
+		format = jsonFormat
+		return nil
+	})
+	level := func(l logLevel) func(string) error {
+		return func(string) error {
+			verbosity = l
+			return nil
+		}
+	}
+	fs.BoolFunc("v", "verbose logging", level(verboseLevel))
+	fs.BoolFunc("verbose", "same as -v", level(verboseLevel))
+	fs.BoolFunc("vv", "trace logging", level(traceLevel))
+	fs.BoolFunc("q", "print only results, warnings and errors", level(quietLevel))
+	fs.BoolFunc("quiet", "same as -q", level(quietLevel))
+	fs.BoolVar(&strictTypes, "strict", false, "consider invalid the manifests with unknown addon types")
+}
+
+func (c *subcommand) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	globalFlags(fs)
+	if c.flags != nil {
+		c.flags(fs)
+	}
+	return fs
+}
+
+// parse allows flags to be mixed with positional arguments until "--" is found
+func (c *subcommand) parse(args []string) (positional []string, err error) {
+	fs := c.flagSet()
+	for {
+		if err = fs.Parse(args); err == flag.ErrHelp {
+			return nil, err
+		} else if err != nil {
+			return nil, failure(errArgs, "%s", err)
+		}
+
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		} else if i := len(args) - len(rest) - 1; i >= 0 && args[i] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional, args = append(positional, rest[0]), rest[1:]
+	}
+
+	switch {
+	case len(positional) < c.min:
+		err = failure(errArgs, "Missing arguments for %s", c.name)
+	case c.max >= 0 && len(positional) > c.max:
+		err = failure(errArgs, "Too many arguments for %s", c.name)
+	}
+	return
+}
+
+func (c *subcommand) usage() string {
+	var b = new(strings.Builder)
+
+	fmt.Fprintf(b, "%s\nUsage:\n lxl %s", c.summary, c.name)
+	if c.args != "" {
+		fmt.Fprint(b, " ", c.args)
+	}
+	b.WriteString(" [flags]\n")
+
+	if c.flags != nil {
+		b.WriteString("Flags:\n")
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.flags(fs)
+		fs.SetOutput(b)
+		fs.PrintDefaults()
+	}
+
+	b.WriteString("Global flags:\n")
+	fs := flag.NewFlagSet("lxl", flag.ContinueOnError)
+	globalFlags(fs)
+	fs.SetOutput(b)
+	fs.PrintDefaults()
+
+	return b.String()
+}
+
+func usage() string {
+	var b = new(strings.Builder)
+
+	b.WriteString("Usage:\n lxl [flags] <command> [arguments]\nCommands:\n")
+	for _, c := range commands {
+		if !c.hidden {
+			fmt.Fprintf(b, " %-12s %s\n", c.name, c.summary)
+		}
+	}
+	b.WriteString("Use \"lxl help <command>\" for more information about a command")
+
+	return b.String()
+}
+
+func dispatch(args []string) error {
+	// Global flags might also come before the command
+	fs := flag.NewFlagSet("lxl", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	globalFlags(fs)
+	if err := fs.Parse(args); err != nil && err != flag.ErrHelp {
+		return failure(errArgs, "%s", err)
+	} else if err == flag.ErrHelp || fs.NArg() == 0 {
+		success("help", usage())
+		return skip
+	}
+
+	args = fs.Args()
+	if current = lookup(args[0]); current == nil {
+		return failure(errArgs, "Unrecognized command %s", args[0])
+	}
+	result.Command = current.name
+
+	positional, err := current.parse(args[1:])
+	if err == flag.ErrHelp {
+		success(current.name, current.usage())
+		return skip
+	} else if err != nil {
+		return err
+	}
+
+	return current.run(positional)
+}
+
+func first(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// each runs the action for every given argument stopping at the first error
+func each(args []string, action func(string) error) error {
+	for _, arg := range args {
+		if err := action(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
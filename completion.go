@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func flagNames(c *subcommand) (names []string) {
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return
+}
+
+func visible() (names []string) {
+	for _, c := range commands {
+		if !c.hidden {
+			names = append(names, c.name)
+		}
+	}
+	return
+}
+
+func completion(shell string) error {
+	var script string
+
+	switch shell {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return failure(errArgs, "Unsupported shell %s", shell)
+	}
+
+	fmt.Print(script)
+	return skip
+}
+
+// complete prints the dynamic candidates for the arguments of a command
+func complete(name string) error {
+	c := lookup(name)
+	if c == nil || c.complete == nil {
+		return skip
+	}
+
+	candidates, err := c.complete()
+	if err != nil {
+		debug("cannot complete %s: %s", name, err)
+		return skip
+	}
+
+	for _, candidate := range candidates {
+		fmt.Println(candidate)
+	}
+	return skip
+}
+
+func storedIDs() (ids []string, err error) {
+	m, err := storedManifest()
+	if err != nil {
+		return
+	}
+
+	for _, a := range m.Addons {
+		if !slices.Contains(ids, a.ID) {
+			ids = append(ids, a.ID)
+		}
+	}
+	return
+}
+
+func installedIDs() (ids []string, err error) {
+	local, err := saved()
+	for id := range local {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return
+}
+
+func subscribedRemotes() ([]string, error) {
+	if err := loadStatus(); err != nil {
+		return nil, err
+	}
+	return cache.Remotes, nil
+}
+
+func commandNames() ([]string, error) {
+	return visible(), nil
+}
+
+func bashCompletion() string {
+	var b = new(strings.Builder)
+
+	b.WriteString(`# bash completion for lxl, load it with: source <(lxl completion bash)
+_lxl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" cmd="" flags="" i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+			-*) ;;
+			*) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	case "$cmd" in
+`)
+	for _, c := range commands {
+		if !c.hidden {
+			fmt.Fprintf(b, "\t\t%s) flags=%q ;;\n", c.name, strings.Join(flagNames(c), " "))
+		}
+	}
+	fmt.Fprintf(b, `		"") COMPREPLY=($(compgen -W %q -- "$cur")); return ;;
+	esac
+
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -W "$(lxl __complete "$cmd" 2>/dev/null)" -- "$cur"))
+	fi
+}
+complete -F _lxl lxl
+`, strings.Join(visible(), " "))
+
+	return b.String()
+}
+
+func zshCompletion() string {
+	var b = new(strings.Builder)
+
+	b.WriteString(`#compdef lxl
+# zsh completion for lxl, load it with: source <(lxl completion zsh)
+_lxl() {
+	local cmd="" flags="" i
+	for ((i = 2; i < CURRENT; i++)); do
+		if [[ "${words[i]}" != -* ]]; then
+			cmd="${words[i]}"
+			break
+		fi
+	done
+
+	case "$cmd" in
+`)
+	for _, c := range commands {
+		if !c.hidden {
+			fmt.Fprintf(b, "\t\t%s) flags=%q ;;\n", c.name, strings.Join(flagNames(c), " "))
+		}
+	}
+	fmt.Fprintf(b, `		"") compadd -- %s; return ;;
+	esac
+
+	if [[ "$PREFIX" == -* ]]; then
+		compadd -- ${=flags}
+	else
+		compadd -- ${(f)"$(lxl __complete "$cmd" 2>/dev/null)"}
+	fi
+}
+compdef _lxl lxl
+`, strings.Join(visible(), " "))
+
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b = new(strings.Builder)
+
+	b.WriteString("# fish completion for lxl, load it with: lxl completion fish | source\ncomplete -c lxl -f\n")
+	for _, c := range commands {
+		if c.hidden {
+			continue
+		}
+
+		fmt.Fprintf(b, "complete -c lxl -n __fish_use_subcommand -a %s -d %q\n", c.name, c.summary)
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			_, usage := flag.UnquoteUsage(f)
+			fmt.Fprintf(b, "complete -c lxl -n '__fish_seen_subcommand_from %s' -l %s -d %q\n", c.name, f.Name, usage)
+		})
+		if c.complete != nil {
+			fmt.Fprintf(b, "complete -c lxl -n '__fish_seen_subcommand_from %s' -a '(lxl __complete %s 2>/dev/null)'\n", c.name, c.name)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type problem struct {
+	Check   string `json:"check"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	Fixed   bool   `json:"fixed"`
+	fix     func() error
+}
+
+var fixProblems bool
+
+// editorModVersion retrieves the mod_version supported by the installed editor
+func editorModVersion() (string, error) {
+	if cache.ModVersion != "" {
+		return cache.ModVersion, nil
+	}
+
+	exe, err := exec.LookPath("lite-xl")
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(exe)
+	rgx := regexp.MustCompile(`MOD_VERSION(?:_MAJOR)?\s*=\s*"?(\d+)`)
+	for _, start := range []string{
+		filepath.Join(dir, "data", "core", "start.lua"),
+		filepath.Join(dir, "..", "share", "lite-xl", "core", "start.lua"),
+		filepath.Join(dir, "..", "Resources", "core", "start.lua"),
+	} {
+		content, err := os.ReadFile(start)
+		if err != nil {
+			continue
+		}
+		if res := rgx.FindSubmatch(content); len(res) == 2 {
+			return string(res[1]), nil
+		}
+	}
+	return "", failure(errNotFound, "Cannot detect lite-xl mod version")
+}
+
+func major(version string) string {
+	if ind := strings.IndexByte(version, '.'); ind >= 0 {
+		return version[:ind]
+	}
+	return version
+}
+
+// repair reinstalls the given addon from the remotes
+func repair(id string, m *manifest) error {
+	if r, ok := cache.installed(id); ok && r.Linked {
+		if err := unlink(id); err != nil {
+			return err
+		}
+		return link(r.Source)
+	} else if ok && r.Source != "" {
+		return installSource(r.Source)
+	}
+
+	found := m.find(id)
+	if found == nil {
+		return failure(errNotFound, "%s is not available anymore on the remotes", id)
+	}
+
+	local, err := saved()
+	if err != nil {
+		return err
+	}
+
+	var p plan
+	if a, ok := local[id]; ok {
+		p.remove(a, "repairing")
+	}
+	return append(p, installPlan(*found, m, local)...).run("Repairing " + id)
+}
+
+func doctor() (err error) {
+	var problems []*problem
+	report := func(p problem) {
+		debug("%s: %s %s", p.Check, p.Subject, p.Message)
+		problems = append(problems, &p)
+	}
+
+	// status.toml must parse
+	path, err := configPath("lxl", "status.toml")
+	if err != nil {
+		return
+	}
+	if err = loadStatus(); codeOf(err) == errStatus {
+		report(problem{Check: "status", Subject: path, Message: err.Error(), fix: func() error {
+			if err := os.Rename(path, path+".broken"); err != nil {
+				return err
+			}
+			warn("status reset", "the broken status has been moved to "+path+".broken")
+			return loadStatus()
+		}})
+	} else if err != nil {
+		return
+	}
+
+	// Stale temporary folders left by clone
+	if entries, e := os.ReadDir(os.TempDir()); e == nil {
+		for _, entry := range entries {
+			info, e := entry.Info()
+			if e != nil || !entry.IsDir() || !strings.HasPrefix(entry.Name(), TEMP_PREFIX) || time.Since(info.ModTime()) < time.Hour {
+				continue
+			}
+
+			stale := filepath.Join(os.TempDir(), entry.Name())
+			report(problem{Check: "temp", Subject: stale, Message: "stale temporary folder", fix: func() error {
+				return os.RemoveAll(stale)
+			}})
+		}
+	}
+
+	if cache == nil {
+		// Nothing else can be checked without a valid status
+		return diagnose(problems)
+	}
+
+	// Unreachable remotes, the manifests of the others are used by the checks
+	manifests := make([]*manifest, len(cache.Remotes))
+	for i, url := range cache.Remotes {
+		var r remoteReport
+		if r, manifests[i] = inspect(url); r.Error != "" {
+			report(problem{Check: "remote", Subject: url, Message: r.Error})
+		}
+	}
+
+	offline := new(manifest)
+	manifest := merge(manifests)
+	if manifest == nil {
+		debug("no valid remote, checking offline")
+		manifest = offline
+	}
+	cache.manifest = manifest
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	ids := make([]string, 0, len(cache.Installed))
+	for id := range cache.Installed {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	modVersion, e := editorModVersion()
+	if e != nil {
+		debug("mod version check skipped: %s", e)
+	}
+
+	for _, id := range ids {
+		id, r := id, cache.Installed[id]
+
+		// Installed files against their recorded checksums
+		var broken []string
+		for _, f := range r.files() {
+			if _, e := os.Stat(f); e != nil {
+				broken = append(broken, f+" is missing")
+			} else if sum, _ := checksum(f); r.Files[f] != "" && sum != r.Files[f] {
+				broken = append(broken, f+" has been modified")
+			}
+		}
+		if len(broken) > 0 {
+			report(problem{Check: "files", Subject: id, Message: strings.Join(broken, ", "), fix: func() error {
+				if _, ok := local[id]; !ok && r.Source == "" && manifest.find(id) == nil {
+					return updateStatus(func(l *lxl) error {
+						l.forget(id)
+						return nil
+					})
+				}
+				return repair(id, manifest)
+			}})
+		}
+
+		// Broken or missing dependencies
+		for _, dep := range r.Dependencies {
+			if _, ok := local[dep]; ok {
+				continue
+			}
+
+			dep := dep
+			report(problem{Check: "dependencies", Subject: id, Message: "missing dependency " + dep, fix: func() error {
+				found := manifest.find(dep)
+				if found == nil {
+					return failure(errNotFound, "%s is not available on the remotes", dep)
+				}
+				var p plan
+				p.download(*found, "dependency of "+id, true)
+				return p.run("Installing " + dep)
+			}})
+		}
+
+		item := manifest.find(id)
+		if item == nil {
+			continue
+		}
+
+		// Addons conflicting with each other
+		for c := range item.Conflicts {
+			if _, ok := local[c]; ok {
+				report(problem{Check: "conflicts", Subject: id, Message: "conflicts with installed " + c})
+			}
+		}
+
+		// Incompatible mod version
+		if modVersion != "" && item.ModVersion != "" && major(item.ModVersion) != major(modVersion) {
+			report(problem{Check: "mod_version", Subject: id, Message: "requires mod_version " + item.ModVersion + " but lite-xl supports " + modVersion})
+		}
+	}
+
+	return diagnose(problems)
+}
+
+func diagnose(problems []*problem) error {
+	unfixed := 0
+	for _, p := range problems {
+		if fixProblems && p.fix != nil {
+			if err := p.fix(); err != nil {
+				warn("Cannot fix "+p.Subject, err)
+			} else {
+				p.Fixed = true
+			}
+		}
+		if !p.Fixed {
+			unfixed++
+		}
+	}
+
+	if plumbing() {
+		for _, p := range problems {
+			result.Problems = append(result.Problems, *p)
+		}
+	} else {
+		showProblems(problems)
+	}
+
+	if unfixed > 0 {
+		return failure(errUnhealthy, "%d problems found", unfixed)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// Extra fields used to restore an exported addon
+const (
+	SOURCE_EXTRA = "source"
+	AUTO_EXTRA   = "auto"
+	ORIGIN_EXTRA = "origin" // git repository or local path of the addons without a remote
+	LINKED_EXTRA = "linked"
+)
+
+// exported builds the manifest describing the installed addons
+func exported() (m manifest, err error) {
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	merged, err := fetchManifest()
+	if err != nil {
+		return
+	}
+	remotes := map[string]*manifest{}
+
+	ids := make([]string, 0, len(local))
+	for id := range local {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		r, ok := cache.installed(id)
+		if !ok {
+			logf(quietLevel, "[skip]", "%s has not been installed by lxl, use \"lxl adopt\" to export it", local[id].Path)
+			continue
+		}
+
+		// The addon is described as it was on its remote
+		a := addon{ID: id, Version: r.Version, AddonsType: r.Type}
+		if found := merged.installedFrom(id, r); found != nil && found.repo == r.Remote {
+			a = *found
+		} else if r.Remote != "" {
+			if _, ok := remotes[r.Remote]; !ok {
+				var e error
+				if remotes[r.Remote], e = fetchManifestAt(r.Remote); e != nil {
+					logf(quietLevel, "[skip]", "%s", e)
+				}
+			}
+			if found := remotes[r.Remote].find(id); found != nil {
+				a = *found
+			}
+		}
+
+		extra := make(map[string]string, len(a.Extra)+2)
+		for k, v := range a.Extra {
+			extra[k] = v
+		}
+		a.Version, a.Extra = r.Version, extra
+		if r.Remote != "" {
+			a.Extra[SOURCE_EXTRA] = r.Remote
+			if !slices.Contains(m.Remotes, r.Remote) {
+				m.Remotes = append(m.Remotes, r.Remote)
+			}
+		}
+		if r.Source != "" {
+			a.Extra[ORIGIN_EXTRA] = r.Source
+		}
+		if r.Linked {
+			a.Extra[LINKED_EXTRA] = strconv.FormatBool(r.Linked)
+		}
+		if r.Auto {
+			a.Extra[AUTO_EXTRA] = strconv.FormatBool(r.Auto)
+		}
+		m.Addons = append(m.Addons, a)
+	}
+
+	slices.SortFunc(m.Remotes, func(a, b string) int {
+		return slices.Index(cache.Remotes, a) - slices.Index(cache.Remotes, b)
+	})
+	return
+}
+
+func export(path string) (err error) {
+	toStdout := path == "" || path == "-"
+	if toStdout {
+		display = os.Stderr
+	}
+
+	m, err := exported()
+	if err != nil {
+		return
+	}
+
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return
+	}
+	raw = append(raw, '\n')
+
+	// The manifest is the only output when written on the standard output,
+	// even with a machine-readable format there is no report to print after it
+	// and the messages are printed on the standard error
+	if toStdout {
+		if _, err = os.Stdout.Write(raw); err == nil {
+			format, err = textFormat, skip
+		}
+		return
+	}
+
+	if err = os.WriteFile(path, raw, 0666); err != nil {
+		return failure(errFilesystem, "Cannot write %s: %s", path, err)
+	}
+	success(current.name, "Exported "+strconv.Itoa(len(m.Addons))+" addons on "+path)
+	return skip
+}
+
+func importAddons(path string) (err error) {
+	var raw []byte
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return failure(errFilesystem, "Cannot read %s: %s", path, err)
+	}
+
+	m := new(manifest)
+	if err = json.Unmarshal(raw, m); err != nil {
+		return failure(errManifest, "Error while parsing %s: %s", path, err)
+	}
+
+	// Restoring the same versions from the same remotes when still possible
+	var sources []addon
+	proj := project{Remotes: m.Remotes, Addons: make(map[string]wanted, len(m.Addons))}
+	for _, a := range m.Addons {
+		if a.Extra[ORIGIN_EXTRA] != "" {
+			sources = append(sources, a)
+			continue
+		}
+		auto, _ := strconv.ParseBool(a.Extra[AUTO_EXTRA])
+		proj.Addons[a.ID] = wanted{Pin: a.Version, Remote: a.Extra[SOURCE_EXTRA], lenient: true, auto: auto}
+	}
+
+	err = proj.apply(path)
+	changed := err == nil
+	if err != nil && err != skip {
+		return
+	}
+	for _, a := range sources {
+		if err = restoreSource(a); err == nil {
+			changed = true
+		} else if err != skip {
+			return
+		}
+	}
+	if !changed {
+		return skip
+	}
+	return nil
+}
+
+// restoreSource installs again an exported addon from its git repository or
+// local path, unless it is already installed from there
+func restoreSource(a addon) error {
+	origin := a.Extra[ORIGIN_EXTRA]
+	if r, ok := cache.installed(a.ID); ok && r.Source == origin {
+		debug("%s is already installed from %s", a.ID, origin)
+		return skip
+	}
+
+	sourceType = a.AddonsType.String()
+	if linked, _ := strconv.ParseBool(a.Extra[LINKED_EXTRA]); !linked {
+		return installSource(origin)
+	} else if dryRun {
+		success(current.name, "Dry run, "+a.ID+" would be linked to "+origin)
+		return nil
+	}
+	return link(origin)
+}
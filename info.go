@@ -0,0 +1,271 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type dependencyNode struct {
+	ID           string           `json:"id"`
+	Constraint   string           `json:"constraint,omitempty"`
+	Optional     bool             `json:"optional,omitempty"`
+	Available    string           `json:"available,omitempty"`
+	Installed    string           `json:"installed,omitempty"`
+	Dependencies []dependencyNode `json:"dependencies,omitempty"`
+}
+
+type addonInfo struct {
+	addon
+	Source    string           `json:"source"`
+	Endpoint  string           `json:"endpoint,omitempty"`
+	Singleton bool             `json:"singleton"`
+	Supported bool             `json:"supported"`
+	Installed *record          `json:"installed,omitempty"`
+	Unmanaged bool             `json:"unmanaged,omitempty"`
+	Tree      []dependencyNode `json:"tree,omitempty"`
+	Changes   []string         `json:"changes,omitempty"`
+	// Alternatives are the other candidates found on the remotes
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+// candidates lists the addons with the given ID, merged manifests keep them in
+// the order of their remotes
+func (m *manifest) candidates(id string) (list []*addon) {
+	for i := range m.Addons {
+		if m.Addons[i].ID == id {
+			list = append(list, &m.Addons[i])
+		}
+	}
+	return
+}
+
+// preferred is the candidate of the first remote, the newest one when the
+// remote has more versions of it
+func preferred(list []*addon) (found *addon) {
+	for _, a := range list {
+		if found == nil || a.repo == found.repo && compareVersions(a.Version, found.Version) > 0 {
+			found = a
+		}
+	}
+	return
+}
+
+func (m *manifest) find(id string) *addon {
+	return preferred(m.candidates(id))
+}
+
+// installedFrom is the candidate of the remote the addon has been installed
+// from, the preferred one when it's not available there anymore
+func (m *manifest) installedFrom(id string, r record) *addon {
+	var same []*addon
+	for _, a := range m.candidates(id) {
+		if a.repo == r.Remote {
+			same = append(same, a)
+		}
+	}
+	if len(same) == 0 {
+		return m.find(id)
+	}
+	return preferred(same)
+}
+
+// choose finds the preferred candidate satisfying the constraints among the
+// ones of the remotes matching the reference, any remote when empty
+func (m *manifest) choose(id, remote string, list constraints) (*addon, error) {
+	path, err := remotePath(remote)
+	if err != nil {
+		return nil, failure(errArgs, "%s", err)
+	}
+
+	var matching []*addon
+	for _, a := range m.candidates(id) {
+		if strings.Contains(a.repo, path) && list.match(a.Version) {
+			matching = append(matching, a)
+		}
+	}
+	return preferred(matching), nil
+}
+
+// specifier describes an addon on the command line as [remote:]id[@version],
+// the version can also be a constraint following the ID like id==1.2 and the
+// remote can follow the ID as in id@remote
+type specifier struct {
+	ID      string
+	Remote  string
+	Version string
+}
+
+func parseSpecifier(spec string) (s specifier) {
+	// What follows @ is a version unless it's not a valid constraint
+	parts := strings.Split(spec, "@")
+	s.ID = parts[0]
+	for _, part := range parts[1:] {
+		if _, err := parseConstraints(part); err == nil && !isRemoteName(part) {
+			s.Version = part
+		} else {
+			s.Remote = part
+		}
+	}
+
+	if ind := strings.IndexAny(s.ID, "=<>!^~"); ind > 0 && s.Version == "" {
+		s.ID, s.Version = s.ID[:ind], s.ID[ind:]
+	}
+
+	// Remotes given as links contains colons too
+	if ind := strings.LastIndexByte(s.ID, ':'); ind > strings.LastIndexByte(s.ID, '/') && s.Remote == "" {
+		s.Remote, s.ID = s.ID[:ind], s.ID[ind+1:]
+	}
+	return
+}
+
+// isRemoteName checks if the name is the host or a folder of the link of a
+// subscribed remote, as 127.0.0.1 that could be mistaken for a version
+func isRemoteName(name string) bool {
+	if cache == nil {
+		return false
+	}
+	for _, r := range cache.Remotes {
+		if u, err := url.Parse(r); err == nil && (u.Hostname() == name || slices.Contains(strings.Split(u.Path, "/"), name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pick finds the addon described by a specifier
+func (m *manifest) pick(spec string) (found *addon, err error) {
+	s := parseSpecifier(spec)
+	if s.ID == "" {
+		return nil, failure(errArgs, "Missing addon ID on %s", spec)
+	}
+
+	list, err := parseConstraints(s.Version)
+	if err != nil {
+		return nil, failure(errArgs, "Invalid version of %s: %s", s.ID, err)
+	} else if found, err = m.choose(s.ID, s.Remote, list); err != nil || found != nil {
+		return
+	}
+
+	var available []string
+	for _, a := range m.candidates(s.ID) {
+		available = append(available, "v"+a.Version+" from "+a.repo)
+	}
+	if len(available) == 0 {
+		return nil, failure(errNotFound, "Cannot find %s addon", s.ID)
+	}
+	return nil, failure(errNotFound, "Cannot find %s matching %s, available: %s", s.ID, spec, strings.Join(available, ", "))
+}
+
+func info(addonID string) (err error) {
+	manifest, err := fetchManifest()
+	if err != nil {
+		return
+	}
+
+	found, err := manifest.pick(addonID)
+	if err != nil {
+		return
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	details := addonInfo{addon: *found, Source: found.repo, Supported: found.supported()}
+	if endpoint, singleton, e := found.endpoint(); e == nil {
+		details.Endpoint, details.Singleton = endpoint, singleton
+	} else {
+		debug("cannot resolve endpoint of %s: %s", found.ID, e)
+	}
+
+	for _, a := range manifest.candidates(found.ID) {
+		if a != found {
+			details.Alternatives = append(details.Alternatives, "v"+a.Version+" from "+a.repo)
+		}
+	}
+
+	if r, ok := cache.installed(found.ID); ok {
+		details.Installed = &r
+	} else if _, ok := local[found.ID]; ok {
+		details.Unmanaged = true
+	}
+
+	version := func(id string) string {
+		if r, ok := cache.installed(id); ok {
+			return r.Version
+		} else if _, ok := local[id]; ok {
+			return "unknown"
+		}
+		return ""
+	}
+
+	// Resolving dependency tree
+	var visit func(a addon, visited []string) []dependencyNode
+	visit = func(a addon, visited []string) (nodes []dependencyNode) {
+		for id, dep := range a.Dependencies {
+			node := dependencyNode{ID: id, Installed: version(id)}
+			if dep != nil {
+				node.Constraint, node.Optional = dep.Version, dep.Optional
+			}
+			if item := manifest.find(id); item != nil {
+				node.Available = item.Version
+				if !slices.Contains(visited, id) {
+					node.Dependencies = visit(*item, append(visited, id))
+				}
+			}
+			nodes = append(nodes, node)
+		}
+		slices.SortFunc(nodes, func(a, b dependencyNode) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+		return
+	}
+	details.Tree = visit(*found, []string{found.ID})
+
+	// Evaluating what installing would change
+	switch v := version(found.ID); v {
+	case "":
+		details.Changes = append(details.Changes, "install "+found.ID+" v"+found.Version)
+	case found.Version:
+	default:
+		details.Changes = append(details.Changes, "replace "+found.ID+" v"+v+" with v"+found.Version)
+	}
+
+	var walk func(nodes []dependencyNode)
+	walk = func(nodes []dependencyNode) {
+		for _, n := range nodes {
+			switch {
+			case n.Installed != "":
+			case n.Available == "" && !n.Optional:
+				details.Changes = append(details.Changes, "missing dependency "+n.ID)
+			case n.Available != "":
+				details.Changes = append(details.Changes, "install dependency "+n.ID+" v"+n.Available)
+			}
+			walk(n.Dependencies)
+		}
+	}
+	walk(details.Tree)
+
+	for id := range found.Conflicts {
+		if version(id) != "" {
+			details.Changes = append(details.Changes, "remove conflicting "+id)
+		}
+	}
+	for _, id := range found.Replaces {
+		if version(id) != "" {
+			details.Changes = append(details.Changes, "uninstall replaced "+id)
+		}
+	}
+	if found.Post != "" {
+		details.Changes = append(details.Changes, "run post command "+string(found.Post))
+	}
+
+	if plumbing() {
+		result.Info = append(result.Info, details)
+	} else {
+		details.show()
+	}
+	return
+}
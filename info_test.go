@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestParseSpecifier(t *testing.T) {
+	tests := []struct {
+		spec string
+		want specifier
+	}{
+		{"lsp", specifier{ID: "lsp"}},
+		{"lsp@1.2", specifier{ID: "lsp", Version: "1.2"}},
+		{"lsp@^1", specifier{ID: "lsp", Version: "^1"}},
+		{"lsp@*", specifier{ID: "lsp", Version: "*"}},
+		{"lsp@latest", specifier{ID: "lsp", Version: "latest"}},
+		{"lsp>=0.9", specifier{ID: "lsp", Version: ">=0.9"}},
+		{"lsp==1.2", specifier{ID: "lsp", Version: "==1.2"}},
+		{"lsp@lite-xl-plugins", specifier{ID: "lsp", Remote: "lite-xl-plugins"}},
+		{"lsp@lite-xl-plugins@1.2", specifier{ID: "lsp", Remote: "lite-xl-plugins", Version: "1.2"}},
+		{"lite-xl-plugins:lsp", specifier{ID: "lsp", Remote: "lite-xl-plugins"}},
+		{"lite-xl-plugins:lsp@~1.2", specifier{ID: "lsp", Remote: "lite-xl-plugins", Version: "~1.2"}},
+		{"https://github.com/lite-xl/lite-xl-plugins:lsp", specifier{ID: "lsp", Remote: "https://github.com/lite-xl/lite-xl-plugins"}},
+		{"https://example.com/manifest.json:lsp>=1", specifier{ID: "lsp", Remote: "https://example.com/manifest.json", Version: ">=1"}},
+		{"https://github.com/lite-xl/lite-xl-plugins", specifier{ID: "https://github.com/lite-xl/lite-xl-plugins"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseSpecifier(tt.spec); got != tt.want {
+			t.Errorf("parseSpecifier(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpecifierRemoteName(t *testing.T) {
+	defer func(old *lxl) { cache = old }(cache)
+	cache = &lxl{Remotes: []string{"http://127.0.0.1:8765/manifest.json"}}
+
+	tests := []struct {
+		spec string
+		want specifier
+	}{
+		{"lsp@127.0.0.1", specifier{ID: "lsp", Remote: "127.0.0.1"}},
+		{"lsp@127.0.0.1@0.9", specifier{ID: "lsp", Remote: "127.0.0.1", Version: "0.9"}},
+		{"lsp@5", specifier{ID: "lsp", Version: "5"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseSpecifier(tt.spec); got != tt.want {
+			t.Errorf("parseSpecifier(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
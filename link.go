@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type linkReport struct {
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	Target string `json:"target"`
+}
+
+// linkPath is where the working copy of the addon is linked
+func (a addon) linkPath() (string, error) {
+	path, err := a.dir()
+	if ext := filepath.Ext(a.source); err == nil && a.AddonsType.singleton(a.source) && !strings.HasSuffix(path, ext) {
+		path += ext
+	}
+	return path, err
+}
+
+// link installs a local addon as a symbolic link to its working copy so the
+// changes are visible right away
+func link(path string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	} else if isLink(path) {
+		return failure(errArgs, "Only local paths can be linked, use \"lxl install %s\" instead", path)
+	}
+
+	a, err := fromSource(path)
+	if err != nil {
+		return
+	} else if !a.AddonsType.known() {
+		return failure(errArgs, "%s has type %s that is not supported", a.ID, a.AddonsType)
+	}
+
+	target, err := a.linkPath()
+	if err != nil {
+		return
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	} else if _, ok := local[a.ID]; ok {
+		return failure(errForbidden, "%s is already installed, uninstall it before linking %s", a.ID, a.source)
+	} else if _, e := os.Lstat(target); e == nil {
+		return failure(errForbidden, "%s already exists", target)
+	}
+
+	debug("linking %s to %s", target, a.source)
+	if err = os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return
+	} else if err = os.Symlink(a.source, target); err != nil {
+		return failure(errFilesystem, "Cannot link %s: %s", a.source, err)
+	}
+
+	err = updateStatus(func(l *lxl) error {
+		l.track(a, map[string]string{target: ""})
+		r := l.Installed[a.ID]
+		r.Linked = true
+		l.Installed[a.ID] = r
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	result.Installed = append(result.Installed, a.ID)
+	success(current.name, a.ID+" linked to "+a.source)
+	return nil
+}
+
+// unlink removes the link of an addon given by ID or by the path of its
+// working copy, the working copy is left untouched
+func unlink(arg string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	id := arg
+	if abs, e := filepath.Abs(arg); e == nil {
+		for rid, r := range cache.Installed {
+			if r.Linked && r.Source == abs {
+				id = rid
+			}
+		}
+	}
+
+	r, ok := cache.installed(id)
+	if !ok || !r.Linked {
+		return failure(errNotFound, "%s is not linked", arg)
+	}
+
+	for _, f := range r.files() {
+		trace("removing link %s", f)
+		if err = os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	err = updateStatus(func(l *lxl) error {
+		l.forget(id)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	result.Removed = append(result.Removed, id)
+	success(current.name, id+" unlinked, "+r.Source+" has been left untouched")
+	return nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	errorSeverity   = "error"
+	warningSeverity = "warning"
+)
+
+// issue is a violation of the manifest SPEC found at a JSON path
+type issue struct {
+	Source   string `json:"source"`
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+var (
+	checksumRgx = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	keyRgx      = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	archRgx     = regexp.MustCompile(`^(x86_64|x86|i386|i686|aarch64|arm64|arm|armv7|riscv64|ppc64le|s390x)-(linux|windows|darwin|android|freebsd|openbsd|netbsd)$`)
+)
+
+type linter struct {
+	source string
+	issues []issue
+}
+
+// member is the JSON path of a key of the object at path
+func member(path, key string) string {
+	if keyRgx.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+func (l *linter) report(severity, path, model string, v ...any) {
+	l.issues = append(l.issues, issue{Source: l.source, Path: path, Severity: severity, Message: fmt.Sprintf(model, v...)})
+}
+
+// validate checks a raw manifest against the SPEC
+func validate(source string, raw []byte) []issue {
+	l := linter{source: source}
+
+	var root any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		l.report(errorSeverity, "$", "Invalid JSON: %s", err)
+		return l.issues
+	}
+
+	obj, ok := root.(map[string]any)
+	if !ok {
+		l.report(errorSeverity, "$", "Expected an object")
+		return l.issues
+	}
+
+	if remotes, ok := obj["remotes"]; ok {
+		list, ok := remotes.([]any)
+		if !ok {
+			l.report(errorSeverity, "$.remotes", "Expected an array of links")
+		}
+		for i, r := range list {
+			if s, ok := r.(string); !ok || !strings.HasPrefix(s, "http") {
+				l.report(errorSeverity, "$.remotes["+strconv.Itoa(i)+"]", "Expected a link")
+			}
+		}
+	}
+
+	// Custom types can be used by the addons of the same manifest
+	names := typeNames()[:builtinTypes]
+	if types, ok := obj["types"]; ok {
+		list, ok := types.([]any)
+		if !ok {
+			l.report(errorSeverity, "$.types", "Expected an array of types")
+		}
+		for i, item := range list {
+			path := "$.types[" + strconv.Itoa(i) + "]"
+			t, ok := item.(map[string]any)
+			if !ok {
+				l.report(errorSeverity, path, "Expected an object")
+				continue
+			}
+
+			info := typeInfo{}
+			info.Name, _ = t["name"].(string)
+			info.Folder, _ = t["folder"].(string)
+			if exts, ok := t["extensions"].([]any); ok {
+				for _, e := range exts {
+					info.Extensions = append(info.Extensions, fmt.Sprint(e))
+				}
+			}
+
+			if err := info.check(); err != nil {
+				l.report(errorSeverity, path, "%s", err)
+			} else if slices.Contains(names, info.Name) {
+				l.report(errorSeverity, path+".name", "Type %s is already defined", info.Name)
+			} else {
+				names = append(names, info.Name)
+			}
+		}
+	}
+
+	addons, ok := obj["addons"].([]any)
+	if _, has := obj["addons"]; has && !ok {
+		l.report(errorSeverity, "$.addons", "Expected an array of addons")
+	}
+
+	// Identifiers and what they provide can be used as dependencies
+	known, seen := map[string]bool{}, map[string][]string{}
+	for _, item := range addons {
+		a, _ := item.(map[string]any)
+		if id, ok := a["id"].(string); ok {
+			known[id] = true
+		}
+		if provides, ok := a["provides"].([]any); ok {
+			for _, p := range provides {
+				if s, ok := p.(string); ok {
+					known[s] = true
+				}
+			}
+		}
+	}
+
+	for i, item := range addons {
+		path := "$.addons[" + strconv.Itoa(i) + "]"
+		a, ok := item.(map[string]any)
+		if !ok {
+			l.report(errorSeverity, path, "Expected an object")
+			continue
+		}
+
+		id, _ := a["id"].(string)
+		if id == "" {
+			l.report(errorSeverity, path+".id", "Missing id")
+		}
+
+		v, ok := a["version"].(string)
+		if !ok {
+			l.report(errorSeverity, path+".version", "Missing version")
+		} else if _, err := parseVersion(v); err != nil {
+			l.report(errorSeverity, path+".version", "%s", err)
+		}
+
+		if versions, dup := seen[id]; dup && id != "" {
+			if slices.Contains(versions, v) {
+				l.report(errorSeverity, path+".id", "Duplicate addon %s v%s", id, v)
+			} else {
+				l.report(warningSeverity, path+".id", "Addon %s is also present with version %s", id, strings.Join(versions, ", "))
+			}
+		}
+		if !slices.Contains(seen[id], v) {
+			seen[id] = append(seen[id], v)
+		}
+
+		if t, ok := a["type"]; ok {
+			if !slices.Contains(names, fmt.Sprint(t)) {
+				l.report(errorSeverity, path+".type", "Unrecognized type %v, expected one of %s", t, strings.Join(names, ", "))
+			}
+		}
+
+		l.checksum(path, a)
+		l.arch(path+".arch", a["arch"])
+
+		if files, ok := a["files"]; ok {
+			list, ok := files.([]any)
+			if !ok {
+				l.report(errorSeverity, path+".files", "Expected an array of files")
+			}
+			for j, f := range list {
+				fpath := path + ".files[" + strconv.Itoa(j) + "]"
+				fobj, ok := f.(map[string]any)
+				if !ok {
+					l.report(errorSeverity, fpath, "Expected an object")
+					continue
+				} else if u, _ := fobj["url"].(string); u == "" {
+					l.report(errorSeverity, fpath+".url", "Missing url")
+				}
+				if p, ok := fobj["path"].(string); ok && !filepath.IsLocal(filepath.FromSlash(p)) {
+					l.report(errorSeverity, fpath+".path", "Invalid path %s, it must be relative to the lite-xl config folder", p)
+				}
+				l.checksum(fpath, fobj)
+				l.arch(fpath+".arch", fobj["arch"])
+			}
+		}
+
+		for _, field := range []string{"dependencies", "conflicts"} {
+			deps, ok := a[field]
+			if !ok {
+				continue
+			}
+			table, ok := deps.(map[string]any)
+			if !ok {
+				l.report(errorSeverity, path+"."+field, "Expected an object")
+				continue
+			}
+
+			ids := make([]string, 0, len(table))
+			for dep := range table {
+				ids = append(ids, dep)
+			}
+			slices.Sort(ids)
+			for _, dep := range ids {
+				dpath := member(path+"."+field, dep)
+				d, ok := table[dep].(map[string]any)
+				if !ok {
+					l.report(errorSeverity, dpath, "Expected an object")
+					continue
+				} else if c, ok := d["version"].(string); ok {
+					if _, err := parseConstraints(c); err != nil {
+						l.report(errorSeverity, dpath+".version", "%s", err)
+					}
+				}
+
+				// Dependencies might be provided by the other remotes
+				if optional, _ := d["optional"].(bool); field == "dependencies" && !optional && !known[dep] {
+					l.report(warningSeverity, dpath, "Dependency %s is not provided by any addon of the manifest, it must be available on another remote", dep)
+				}
+			}
+		}
+	}
+
+	return l.issues
+}
+
+func (l *linter) checksum(path string, obj map[string]any) {
+	c, ok := obj["checksum"]
+	if !ok {
+		return
+	}
+	if s, _ := c.(string); s != "SKIP" && !checksumRgx.MatchString(s) {
+		l.report(errorSeverity, path+".checksum", "Malformed checksum, expected a sha256 hex digest or SKIP")
+	}
+}
+
+func (l *linter) arch(path string, value any) {
+	var list []any
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		list = []any{v}
+	case []any:
+		list = v
+	default:
+		l.report(errorSeverity, path, "Expected a string or an array of strings")
+		return
+	}
+
+	for i, a := range list {
+		s, _ := a.(string)
+		if s != "*" && !archRgx.MatchString(s) {
+			p := path
+			if _, ok := value.([]any); ok {
+				p += "[" + strconv.Itoa(i) + "]"
+			}
+			l.report(errorSeverity, p, "Unrecognized arch %v", a)
+		}
+	}
+}
+
+func lint(sources []string) (err error) {
+	var all []issue
+	for _, src := range sources {
+		var raw []byte
+		if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+			endpoint, _, e := evaluate(src)
+			if e != nil {
+				return failure(errArgs, "%s", e)
+			}
+			if raw, e = get(endpoint); e != nil {
+				return failure(errNetwork, "Cannot retrieve manifest from %s: %s", endpoint, e)
+			}
+		} else if raw, err = os.ReadFile(src); err != nil {
+			return failure(errFilesystem, "Cannot read %s: %s", src, err)
+		}
+
+		issues := validate(src, raw)
+		debug("%d issues found on %s", len(issues), src)
+		all = append(all, issues...)
+	}
+
+	showIssues(all)
+
+	errors := 0
+	for _, i := range all {
+		if i.Severity == errorSeverity {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return failure(errManifest, "%d errors found", errors)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	checksum := `"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"`
+	tests := []struct {
+		name string
+		raw  string
+		want []issue
+	}{
+		{"valid", `{"addons": [{"id": "a", "version": "1.0", "checksum": ` + checksum + `}]}`, nil},
+		{"invalid json", `{`, []issue{{Path: "$", Severity: errorSeverity}}},
+		{"not an object", `[]`, []issue{{Path: "$", Severity: errorSeverity}}},
+		{"remote", `{"remotes": ["ftp://x"]}`, []issue{{Path: "$.remotes[0]", Severity: errorSeverity}}},
+		{"missing id", `{"addons": [{"version": "1.0"}]}`, []issue{{Path: "$.addons[0].id", Severity: errorSeverity}}},
+		{"missing version", `{"addons": [{"id": "a"}]}`, []issue{{Path: "$.addons[0].version", Severity: errorSeverity}}},
+		{"bad version", `{"addons": [{"id": "a", "version": "x"}]}`, []issue{{Path: "$.addons[0].version", Severity: errorSeverity}}},
+		{"duplicate", `{"addons": [{"id": "a", "version": "1"}, {"id": "a", "version": "1"}]}`, []issue{{Path: "$.addons[1].id", Severity: errorSeverity}}},
+		{"other version", `{"addons": [{"id": "a", "version": "1"}, {"id": "a", "version": "2"}]}`, []issue{{Path: "$.addons[1].id", Severity: warningSeverity}}},
+		{"type", `{"addons": [{"id": "a", "version": "1", "type": "x"}]}`, []issue{{Path: "$.addons[0].type", Severity: errorSeverity}}},
+		{"type folder", `{"types": [{"name": "x", "folder": "."}]}`, []issue{{Path: "$.types[0]", Severity: errorSeverity}}},
+		{"custom type", `{"types": [{"name": "x", "folder": "xs"}], "addons": [{"id": "a", "version": "1", "type": "x"}]}`, nil},
+		{"checksum", `{"addons": [{"id": "a", "version": "1", "files": [{"url": "x", "checksum": "abc"}]}]}`, []issue{{Path: "$.addons[0].files[0].checksum", Severity: errorSeverity}}},
+		{"skip checksum", `{"addons": [{"id": "a", "version": "1", "checksum": "SKIP"}]}`, nil},
+		{"file path", `{"addons": [{"id": "a", "version": "1", "files": [{"url": "x", "path": "/tmp/a.lua"}, {"url": "x", "path": "../a.lua"}, {"url": "x", "path": "colors/a.lua"}]}]}`, []issue{{Path: "$.addons[0].files[0].path", Severity: errorSeverity}, {Path: "$.addons[0].files[1].path", Severity: errorSeverity}}},
+		{"file url", `{"addons": [{"id": "a", "version": "1", "files": [{}]}]}`, []issue{{Path: "$.addons[0].files[0].url", Severity: errorSeverity}}},
+		{"arch", `{"addons": [{"id": "a", "version": "1", "arch": "x86_64-linux"}]}`, nil},
+		{"arch list", `{"addons": [{"id": "a", "version": "1", "arch": ["*", "amd64"]}]}`, []issue{{Path: "$.addons[0].arch[1]", Severity: errorSeverity}}},
+		{"constraint", `{"addons": [{"id": "a", "version": "1", "dependencies": {"b": {"version": ">>1"}}}, {"id": "b", "version": "1"}]}`, []issue{{Path: "$.addons[0].dependencies.b.version", Severity: errorSeverity}}},
+		{"provided", `{"addons": [{"id": "a", "version": "1", "dependencies": {"c": {}}}, {"id": "b", "version": "1", "provides": ["c"]}]}`, nil},
+		{"optional", `{"addons": [{"id": "a", "version": "1", "dependencies": {"c": {"optional": true}}}]}`, nil},
+		{"external", `{"addons": [{"id": "a", "version": "1", "dependencies": {"lite-xl.c": {}}}]}`, []issue{{Path: `$.addons[0].dependencies["lite-xl.c"]`, Severity: warningSeverity}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validate("test", []byte(tt.raw))
+			same := slices.EqualFunc(got, tt.want, func(a, b issue) bool {
+				return a.Path == b.Path && a.Severity == b.Severity
+			})
+			if !same {
+				t.Errorf("validate(%s) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LOCK_FILE is the default file written by lock and read by install --locked
+const LOCK_FILE = "lxl.lock"
+
+type lockedRemote struct {
+	Commit   string `toml:"commit,omitempty"`
+	Checksum string `toml:"checksum"`
+}
+
+type lockedAddon struct {
+	Version      string     `toml:"version"`
+	Type         addonsType `toml:"type"`
+	Remote       string     `toml:"remote"`
+	Commit       string     `toml:"commit,omitempty"`
+	Dependencies []string   `toml:"dependencies,omitempty"`
+	Auto         bool       `toml:"auto,omitempty"`
+	// Files maps every artifact relative to the lite-xl config folder to its checksum
+	Files map[string]string `toml:"files,omitempty"`
+}
+
+type lockFile struct {
+	Remotes map[string]lockedRemote `toml:"remotes"`
+	Addons  map[string]lockedAddon  `toml:"addons"`
+}
+
+var (
+	lockPath string
+	locked   bool
+)
+
+var shaRgx = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// rawGithub splits a raw.githubusercontent.com link into the repository, the
+// git reference and the path of the file
+func rawGithub(endpoint string) (repo, ref, file string, ok bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil || strings.ToLower(u.Host) != GITHUB_RAW_HOST {
+		return
+	}
+
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 4)
+	if len(parts) != 4 {
+		return
+	}
+	return "https://" + GITHUB_HOST + "/" + parts[0] + "/" + parts[1], parts[2], parts[3], true
+}
+
+// lsRemote resolves a git reference of a repository to its commit
+func lsRemote(repo, ref string) (string, error) {
+	if shaRgx.MatchString(ref) {
+		return ref, nil
+	}
+
+	cmd := exec.Command("git", "ls-remote", repo, ref)
+	debug("running %s", cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", failure(errNetwork, "Cannot resolve %s of %s: %s", ref, repo, err)
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", failure(errNotFound, "Cannot find %s on %s", ref, repo)
+	}
+	return fields[0], nil
+}
+
+// at is the link of the manifest at the locked commit
+func (r lockedRemote) at(endpoint string) string {
+	if repo, _, file, ok := rawGithub(endpoint); ok && r.Commit != "" {
+		return strings.Replace(repo, GITHUB_HOST, GITHUB_RAW_HOST, 1) + "/" + r.Commit + "/" + file
+	}
+	return endpoint
+}
+
+func sum(content []byte) string {
+	s := sha256.Sum256(content)
+	return hex.EncodeToString(s[:])
+}
+
+// lockRemote retrieves the current commit and checksum of a remote manifest
+func lockRemote(endpoint string) (r lockedRemote, m *manifest, err error) {
+	if repo, ref, _, ok := rawGithub(endpoint); ok {
+		if r.Commit, err = lsRemote(repo, ref); err != nil {
+			return
+		}
+	}
+
+	link := r.at(endpoint)
+	raw, err := get(link)
+	if err != nil {
+		return r, nil, failure(errNetwork, "Cannot retrieve manifest from %s: %s", endpoint, err)
+	} else if err = cache.verify(endpoint, link, raw); err != nil {
+		return r, nil, failure(errManifest, "Cannot verify manifest from %s: %s", endpoint, err)
+	}
+	r.Checksum = sum(raw)
+
+	m = new(manifest)
+	if err = json.Unmarshal(raw, m); err != nil {
+		err = failure(errManifest, "Error while parsing manifest from %s: %s", endpoint, err)
+	}
+	for i := range m.Addons {
+		m.Addons[i].repo = endpoint
+	}
+	return
+}
+
+// installedCommit is the commit an addon coming from a git repository has
+// been installed at, empty for the other addons
+func (a addon) installedCommit(r record) (string, error) {
+	if a.AddonsType == meta {
+		return "", nil
+	}
+
+	endpoint, singleton, err := a.endpoint()
+	if err != nil || singleton || r.Commit != "" {
+		return r.Commit, err
+	}
+
+	// Repositories cloned in place and links to a commit tell it anyway
+	if dir, e := a.dir(); e == nil {
+		if commit, e := headCommit(dir); e == nil {
+			return commit, nil
+		}
+	}
+	if _, _, commit, e := extract(endpoint); e == nil && shaRgx.MatchString(commit) {
+		return commit, nil
+	}
+	return "", failure(errLocked, "Cannot find the commit %s has been installed at, uninstall and install it again before locking", a.ID)
+}
+
+func lock() (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	root, err := configPath()
+	if err != nil {
+		return
+	}
+
+	lf := lockFile{Remotes: make(map[string]lockedRemote), Addons: make(map[string]lockedAddon)}
+	manifests := make(map[string]*manifest)
+	for id, r := range cache.Installed {
+		if _, ok := manifests[r.Remote]; !ok && r.Remote != "" {
+			var rl lockedRemote
+			if rl, manifests[r.Remote], err = lockRemote(r.Remote); err != nil {
+				return
+			}
+			lf.Remotes[r.Remote] = rl
+		}
+
+		la := lockedAddon{Version: r.Version, Type: r.Type, Remote: r.Remote, Dependencies: r.Dependencies, Auto: r.Auto}
+		if r.Source != "" {
+			warn("Cannot lock "+id, "it has been installed from "+r.Source+" instead of a remote")
+			continue
+		} else if m := manifests[r.Remote]; m == nil {
+			warn("Cannot lock "+id, "its remote is unknown, use \"lxl upgrade "+id+"\" to reinstall it")
+			continue
+		} else if a := m.find(id); a == nil || a.Version != r.Version {
+			return failure(errNotFound, "%s v%s is not available anymore on %s, upgrade it before locking", id, r.Version, r.Remote)
+		} else if la.Commit, err = a.installedCommit(r); err != nil {
+			return
+		}
+
+		la.Files = make(map[string]string, len(r.Files))
+		for path, checksum := range r.Files {
+			if rel, e := filepath.Rel(root, path); e == nil {
+				path = filepath.ToSlash(rel)
+			}
+			la.Files[path] = checksum
+		}
+		lf.Addons[id] = la
+	}
+
+	content, err := toml.Marshal(lf)
+	if err != nil {
+		return
+	}
+
+	if err = os.WriteFile(lockPath, content, 0666); err != nil {
+		return failure(errFilesystem, "Cannot write %s: %s", lockPath, err)
+	}
+	success(current.name, "Locked "+strconv.Itoa(len(lf.Addons))+" addons on "+lockPath)
+	return skip
+}
+
+func loadLock(path string) (lf lockFile, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lf, failure(errNotFound, "Cannot find %s, use \"lxl lock\" to create it", path)
+		}
+		return lf, failure(errFilesystem, "Cannot read %s: %s", path, err)
+	}
+
+	if err = toml.Unmarshal(content, &lf); err != nil {
+		err = failure(errManifest, "Cannot parse %s: %s", path, err)
+	}
+	return
+}
+
+// pinned is the addon described by the lock, it's downloaded exactly from the
+// locked commits
+func (la lockedAddon) pinned(id string, m *manifest, at string) (a addon, err error) {
+	found := m.find(id)
+	if found == nil {
+		return a, failure(errLocked, "%s is not available anymore on %s", id, la.Remote)
+	} else if found.Version != la.Version {
+		return a, failure(errLocked, "%s changed from v%s to v%s on %s", id, la.Version, found.Version, la.Remote)
+	}
+
+	// Links relative to the remote are resolved at the locked commit
+	a = *found
+	if a.Url == "" && (a.Remote == "" && len(a.Files) == 0 || a.Remote != "" && !strings.HasPrefix(a.Remote, "http")) {
+		a.repo = at
+		if a.Url, _, err = a.endpoint(); err != nil {
+			return
+		}
+		a.Remote = ""
+	}
+
+	if la.Commit != "" {
+		endpoint, _, e := a.endpoint()
+		if e != nil {
+			return a, e
+		}
+		repo, _, _, e := extract(endpoint)
+		if e != nil {
+			return a, e
+		}
+		a.Url, a.Remote = repo+":"+la.Commit, ""
+	}
+	return
+}
+
+// changed lists the artifacts that differ from the lock, the ones created
+// by post commands are missing until they run so they are checked only when
+// the artifacts are complete
+func (la lockedAddon) changed(files map[string]string, root string, complete bool) (list []string) {
+	have := make(map[string]string, len(files))
+	for path, checksum := range files {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = filepath.ToSlash(rel)
+		}
+		have[path] = checksum
+	}
+
+	for path, checksum := range have {
+		if c, ok := la.Files[path]; !ok || c != checksum {
+			list = append(list, path)
+		}
+	}
+	if complete {
+		for path := range la.Files {
+			if _, ok := have[path]; !ok {
+				list = append(list, path)
+			}
+		}
+	}
+	slices.Sort(list)
+	return
+}
+
+// intact checks that the locked artifacts on disk have not been modified
+func (la lockedAddon) intact(root string) bool {
+	for path, checksum := range la.Files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if _, err := os.Stat(path); err != nil {
+			return false
+		} else if c, _ := fingerprint(path); checksum != "" && c[path] != checksum {
+			return false
+		}
+	}
+	return true
+}
+
+// installLocked reproduces the addons set described by the lock file
+func installLocked(ids []string) (err error) {
+	lf, err := loadLock(lockPath)
+	if err != nil {
+		return
+	} else if err = loadStatus(); err != nil {
+		return
+	}
+
+	root, err := configPath()
+	if err != nil {
+		return
+	}
+
+	if len(ids) == 0 {
+		for id := range lf.Addons {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	// Verifying everything before touching the disk
+	manifests := make(map[string]*manifest)
+	var (
+		p     plan
+		visit func(id string) error
+	)
+	visit = func(id string) error {
+		la, ok := lf.Addons[id]
+		if !ok {
+			return failure(errNotFound, "%s is not locked on %s", id, lockPath)
+		} else if p.has(downloadStep, id) {
+			return nil
+		}
+
+		for _, dep := range la.Dependencies {
+			if _, ok := lf.Addons[dep]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+
+		if r, ok := cache.installed(id); ok && r.Version == la.Version && la.intact(root) {
+			debug("%s v%s is already installed as locked", id, la.Version)
+			return nil
+		}
+
+		rl, ok := lf.Remotes[la.Remote]
+		if !ok {
+			return failure(errLocked, "%s remote is not locked on %s", la.Remote, lockPath)
+		}
+		m, ok := manifests[la.Remote]
+		if !ok {
+			raw, err := get(rl.at(la.Remote))
+			if err != nil {
+				return failure(errNetwork, "Cannot retrieve manifest from %s: %s", la.Remote, err)
+			} else if sum(raw) != rl.Checksum {
+				return failure(errLocked, "Manifest of %s has changed since it has been locked", la.Remote)
+			} else if err = cache.verify(la.Remote, rl.at(la.Remote), raw); err != nil {
+				return failure(errManifest, "Cannot verify manifest from %s: %s", la.Remote, err)
+			}
+
+			m = new(manifest)
+			if err = json.Unmarshal(raw, m); err != nil {
+				return failure(errManifest, "Error while parsing manifest from %s: %s", la.Remote, err)
+			}
+			manifests[la.Remote] = m
+		}
+
+		a, err := la.pinned(id, m, rl.at(la.Remote))
+		if err != nil {
+			return err
+		}
+		a.repo = la.Remote
+
+		if old, ok := local[id]; ok {
+			p.remove(old, "restoring v"+la.Version)
+		}
+		p.download(a, "locked", la.Auto)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err = visit(id); err != nil {
+			return
+		}
+	}
+
+	if len(p) == 0 {
+		success(current.name, "Everything is already installed as locked")
+		return skip
+	}
+
+	// Anything different from the lock is refused before touching the disk
+	verify := func(s staged) error {
+		artifacts, err := s.artifacts()
+		if err != nil {
+			return err
+		}
+
+		la := lf.Addons[s.ID]
+		if changed := la.changed(artifacts, root, s.Post == ""); len(changed) > 0 {
+			return failure(errLocked, "%s differs from the lock: %s", s.ID, strings.Join(changed, ", "))
+		} else if s.commit != la.Commit {
+			return failure(errLocked, "%s has been checked out at %s instead of %s", s.ID, s.commit, la.Commit)
+		}
+		return nil
+	}
+	if err = p.runVerified("Installing from "+lockPath, verify); err != nil || dryRun {
+		return
+	}
+
+	return updateStatus(func(l *lxl) error {
+		for _, s := range p {
+			if s.Action == downloadStep {
+				l.setAuto(s.ID, lf.Addons[s.ID].Auto)
+			}
+		}
+		return nil
+	})
+}
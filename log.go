@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DazFather/brush"
+)
+
+type logLevel int8
+
+const (
+	quietLevel logLevel = iota - 1
+	normalLevel
+	verboseLevel
+	traceLevel
+)
+
+var verbosity = normalLevel
+
+func logf(level logLevel, tag, model string, v ...any) {
+	if verbosity < level {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, brush.Join(
+		brush.Paint(brush.BrightBlack, nil, tag),
+		" ", fmt.Sprintf(model, v...),
+	))
+}
+
+// debug logs when running with -v
+func debug(model string, v ...any) {
+	logf(verboseLevel, "[debug]", model, v...)
+}
+
+// trace logs when running with -vv
+func trace(model string, v ...any) {
+	logf(traceLevel, "[trace]", model, v...)
+}
+
+// hint prints additional informations unless running quiet or plumbing
+func hint(v ...any) {
+	if verbosity >= normalLevel && !plumbing() {
+		fmt.Fprint(display, v...)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"slices"
@@ -8,61 +9,333 @@ import (
 	"strings"
 )
 
+var commands []*subcommand
+
+func init() {
+	commands = []*subcommand{
+		{
+			name: "find", args: "[addon]", max: 1,
+			summary:  "Find any addon from the subscribed remotes",
+			complete: storedIDs,
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&filter.kind, "type", "", "only addons of the given `type`")
+				fs.StringVar(&filter.tag, "tag", "", "only addons with the given `tag`")
+				fs.StringVar(&filter.remote, "remote", "", "only addons coming from a `remote` containing the given text")
+			},
+			run: func(args []string) error {
+				return find(first(args))
+			},
+		},
+		{
+			name: "info", args: "[remote:]<addonID>[@version]...", min: 1, max: -1,
+			summary:  "Show every detail about one or more addons",
+			complete: storedIDs,
+			run: func(args []string) error {
+				return each(args, info)
+			},
+		},
+		{
+			name: "install", args: "([remote:]<addonID>[@version] | <repository> | <path>)...", max: -1,
+			summary:  "Install one or more addons with their dependencies",
+			complete: storedIDs,
+			flags: func(fs *flag.FlagSet) {
+				planFlags(fs)
+				fs.BoolVar(&locked, "locked", false, "install exactly what is locked, every locked addon if none is given")
+				fs.StringVar(&lockPath, "lock-file", LOCK_FILE, "`path` of the lock file used with --locked")
+				fs.StringVar(&sourceType, "type", "", "`type` of the addons installed from a git repository or a local path, guessed when not given")
+			},
+			run: func(args []string) error {
+				if locked {
+					return installLocked(args)
+				} else if len(args) == 0 {
+					return failure(errArgs, "Missing arguments for %s", current.name)
+				}
+				return each(args, func(arg string) error {
+					if err := loadStatus(); err != nil {
+						return err
+					} else if isSource(arg) {
+						return installSource(arg)
+					}
+					return install(arg)
+				})
+			},
+		},
+		{
+			name: "uninstall", args: "<addonID>...", min: 1, max: -1,
+			summary:  "Uninstall one or more addons",
+			complete: installedIDs,
+			flags: func(fs *flag.FlagSet) {
+				planFlags(fs)
+				fs.BoolVar(&cascade, "cascade", false, "uninstall also the addons that depends on the given ones")
+			},
+			run: uninstall,
+		},
+		{
+			name: "upgrade", args: "[addonID]...", max: -1,
+			summary:  "Upgrade the installed addons to the version available on the remotes",
+			complete: installedIDs,
+			flags:    planFlags,
+			run:      upgrade,
+		},
+		{
+			name: "autoremove", max: 0,
+			summary: "Remove the dependencies that are not needed anymore",
+			flags:   planFlags,
+			run: func([]string) error {
+				return autoremove()
+			},
+		},
+		{
+			name: "sync", max: 0,
+			summary: "Install, upgrade or downgrade the addons to match the " + PROJECT_FILE + " file",
+			flags: func(fs *flag.FlagSet) {
+				planFlags(fs)
+				fs.StringVar(&projectFile, "file", PROJECT_FILE, "`path` of the file listing the wanted remotes and addons")
+				fs.BoolVar(&pruneSync, "prune", false, "uninstall the addons that are not listed nor required")
+			},
+			run: func([]string) error {
+				return syncProject()
+			},
+		},
+		{
+			name: "lock", max: 0,
+			summary: "Write the exact versions, commits and checksums of the installed addons",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&lockPath, "file", LOCK_FILE, "`path` of the lock file to write")
+			},
+			run: func([]string) error {
+				return lock()
+			},
+		},
+		{
+			name: "export", args: "[file]", max: 1,
+			summary: "Export the installed addons as a manifest, on the standard output if no file is given",
+			run: func(args []string) error {
+				return export(first(args))
+			},
+		},
+		{
+			name: "import", args: "<file>", min: 1, max: 1,
+			summary: "Install the addons exported on a manifest, use - to read the standard input",
+			flags:   planFlags,
+			run: func(args []string) error {
+				return importAddons(args[0])
+			},
+		},
+		{
+			name: "list", args: "[addon]", max: 1,
+			summary:  "List installed addons",
+			complete: installedIDs,
+			run: func(args []string) error {
+				return list(first(args))
+			},
+		},
+		{
+			name: "link", args: "<path>...", min: 1, max: -1,
+			summary: "Link the working copy of one or more addons so every change is visible right away",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&sourceType, "type", "", "`type` of the linked addons, guessed when not given")
+			},
+			run: func(args []string) error {
+				return each(args, link)
+			},
+		},
+		{
+			name: "unlink", args: "<addonID|path>...", min: 1, max: -1,
+			summary:  "Remove the links of the given addons leaving their working copy untouched",
+			complete: installedIDs,
+			run: func(args []string) error {
+				return each(args, unlink)
+			},
+		},
+		{
+			name: "adopt", max: 0,
+			summary: "Adopt the addons installed manually matching them with the remotes",
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&adoptByID, "by-id", false, "adopt also the addons that cannot be verified matching them by ID")
+			},
+			run: func([]string) error {
+				return adopt()
+			},
+		},
+		{
+			name: "doctor", max: 0,
+			summary: "Check the integrity of the local installation",
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&fixProblems, "fix", false, "repair the problems that can be fixed")
+			},
+			run: func([]string) error {
+				return doctor()
+			},
+		},
+		{
+			name: "profile", args: "<create|use|list|delete> [name]", min: 1, max: 2,
+			summary: "Manage profiles, each with its own remotes and addons",
+			complete: func() ([]string, error) {
+				names, err := profileNames()
+				return append([]string{"create", "use", "list", "delete"}, names...), err
+			},
+			run: func(args []string) error {
+				return profile(args[0], first(args[1:]))
+			},
+		},
+		{
+			name: "init", args: "[folder]", max: 1,
+			summary: "Create the " + MANIFEST_FILE + " of a repository with the addons it contains",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&sourceType, "type", "", "`type` of the addons found, guessed when not given")
+			},
+			run: func(args []string) error {
+				dir := first(args)
+				if dir == "" {
+					dir = "."
+				}
+				return initManifest(dir)
+			},
+		},
+		{
+			name: "manifest", args: "add <path>...", min: 2, max: -1,
+			summary: "Add or update the addons at the given paths on a manifest, computing their checksums and versions",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&manifestPath, "file", MANIFEST_FILE, "`path` of the manifest")
+				fs.StringVar(&addonVersion, "version", "", "`version` of the given addons, increased when their content changes if not given")
+				fs.StringVar(&sourceType, "type", "", "`type` of the given addons, guessed when not given")
+			},
+			run: func(args []string) error {
+				if args[0] != "add" {
+					return failure(errArgs, "Unrecognized manifest action %s", args[0])
+				}
+				return manifestAdd(args[1:])
+			},
+		},
+		{
+			name: "publish", max: 0,
+			summary: "Update every addon of a manifest with the current content of its repository",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&manifestPath, "file", MANIFEST_FILE, "`path` of the manifest")
+			},
+			run: func([]string) error {
+				return publish()
+			},
+		},
+		{
+			name: "lint", args: "<manifest>...", min: 1, max: -1,
+			summary: "Validate one or more manifest files or links against the SPEC",
+			run:     lint,
+		},
+		{
+			name: "subscribe", args: "<remote>...", min: 1, max: -1,
+			summary: "Subscribe to one or more remotes",
+			run: func(args []string) error {
+				return each(args, subscribe)
+			},
+		},
+		{
+			name: "unsubscribe", args: "<remote>...", min: 1, max: -1,
+			summary:  "Unsubscribe from one or more remotes",
+			complete: subscribedRemotes,
+			run: func(args []string) error {
+				return each(args, unsubscribe)
+			},
+		},
+		{
+			name: "remotes", args: "[filter]", max: 1,
+			summary: "List subscribed remotes",
+			run: func(args []string) error {
+				return remotes(first(args))
+			},
+		},
+		{
+			name: "trust", args: "<remote>...", min: 1, max: -1,
+			summary:  "Trust one or more remotes",
+			complete: subscribedRemotes,
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&trustKey, "key", "", "ed25519 public `key` (hex or base64) verifying the manifest of the remote")
+				fs.StringVar(&trustSignature, "signature", "", "`link` of the manifest signature, the manifest link followed by .sig if not given")
+			},
+			run: func(args []string) error {
+				return each(args, func(repo string) error {
+					return trust(repo, trusted)
+				})
+			},
+		},
+		{
+			name: "distrust", args: "<remote>...", min: 1, max: -1,
+			summary:  "Distrust one or more remotes",
+			complete: subscribedRemotes,
+			run: func(args []string) error {
+				return each(args, func(repo string) error {
+					return trust(repo, untrusted)
+				})
+			},
+		},
+		{
+			name: "help", args: "[command]", max: 1,
+			summary:  "Show help about lxl or a command",
+			complete: commandNames,
+			run:      help,
+		},
+		{
+			name: "completion", args: "<bash|zsh|fish>", min: 1, max: 1,
+			summary: "Generate the shell completion script",
+			complete: func() ([]string, error) {
+				return []string{"bash", "zsh", "fish"}, nil
+			},
+			run: func(args []string) error {
+				return completion(args[0])
+			},
+		},
+		{
+			name: "__complete", args: "<command>", min: 1, max: 1, hidden: true,
+			summary: "Print the completion candidates of a command",
+			run: func(args []string) error {
+				return complete(args[0])
+			},
+		},
+	}
+}
+
 func main() {
 	var err error
 	onExit := func() {
-		if err == nil {
-			success(os.Args[1], "Completed successfully")
+		if plumbing() {
+			result.flush(err)
+		} else if err == nil {
+			success(current.name, "Completed successfully")
+		} else if codeOf(err) == errArgs {
+			if current != nil {
+				warn(err, current.usage())
+			} else {
+				warn(err, usage())
+			}
 		} else if err != skip {
-			danger("Unable to "+os.Args[1], err)
+			danger("Unable to "+current.name, err)
 		}
-	}
-	defer onExit()
 
-	switch len(os.Args) {
-	case 2:
-		switch os.Args[1] {
-		case "help":
-			success(os.Args[1], USAGE)
-			err = skip
-			return
-		case "list":
-			err = list("")
-			return
-		case "find":
-			err = find("")
-			return
-		case "remotes":
-			err = remotes("")
-			return
+		if err != nil && err != skip {
+			os.Exit(1)
 		}
-		fallthrough
-	case 0, 1:
-		warn("Invalid arguments", USAGE)
-		err = skip
-		return
 	}
+	defer onExit()
 
-	switch os.Args[1] {
-	case "list":
-		err = list(os.Args[2])
-	case "install":
-		err = install(os.Args[2])
-	case "uninstall":
-		err = uninstall(os.Args[2])
-	case "find":
-		err = find(os.Args[2])
-	case "subscribe":
-		err = subscribe(os.Args[2])
-	case "unsubscribe":
-		err = unsubscribe(os.Args[2])
-	case "remotes":
-		err = remotes(os.Args[2])
-	default:
-		danger("Unrecognized command", USAGE)
-		err = skip
-		return
+	err = dispatch(os.Args[1:])
+}
+
+var cascade bool
+
+func planFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&dryRun, "dry-run", false, "show what would be done without touching the disk")
+}
+
+func help(args []string) error {
+	if len(args) == 0 {
+		success("help", usage())
+	} else if c := lookup(args[0]); c != nil {
+		success(c.name, c.usage())
+	} else {
+		return failure(errArgs, "Unrecognized command %s", args[0])
 	}
+	return skip
 }
 
 func find(addonID string) (err error) {
@@ -73,43 +346,111 @@ func find(addonID string) (err error) {
 	}
 
 	// Finding addon
-	var found []addon
-	if addonID == "" {
-		found = manifest.Addons
-	} else {
-		addonID = strings.ToLower(addonID)
-		for _, item := range manifest.Addons {
-			if strings.Contains(strings.ToLower(item.ID), addonID) {
-				found = append(found, item)
-			}
+	// Only the preferred candidate of every addon is shown
+	var addons []addon
+	for i, a := range manifest.Addons {
+		if manifest.find(a.ID) == &manifest.Addons[i] {
+			addons = append(addons, a)
 		}
 	}
 
-	if err = showAddons(os.Args[1], found); addonID == "" && err == nil {
-		success(os.Args[1]+" tip", "Use ", os.Args[1], " followed by something to filter results")
+	found := search(addons, addonID)
+	debug("%d addons out of %d matching \"%s\"", len(found), len(addons), addonID)
+	if err = showAddons(current.name, found); addonID == "" && err == nil {
+		success(current.name+" tip", "Use ", current.name, " followed by something to filter results")
 	}
 	return
 }
 
-func uninstall(addonID string) (err error) {
-	var delted bool
+func uninstall(ids []string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	var (
+		p     plan
+		visit func(id, reason string) error
+	)
+	visit = func(id, reason string) error {
+		if p.has(removeStep, id) {
+			return nil
+		}
 
-	err = rangeSaved(func(a addon) (e error) {
-		if a.ID == addonID {
-			if e = remove(a.Path); e == nil {
-				delted = true
+		for _, d := range cache.dependents(id, local) {
+			if slices.Contains(ids, d) {
+				continue
+			} else if !cascade {
+				return failure(errRequired, "%s is required by %s, use --cascade to uninstall it too", id, d)
+			}
+			if err := visit(d, "depends on "+id); err != nil {
+				return err
 			}
 		}
-		return
-	})
 
-	if err == nil && !delted {
-		err = fmt.Errorf("Cannot find \"%s\" addon", addonID)
+		p.remove(local[id], reason)
+		return nil
+	}
+
+	for _, id := range ids {
+		if _, ok := local[id]; !ok {
+			return failure(errNotFound, "Cannot find \"%s\" addon", id)
+		} else if err = visit(id, ""); err != nil {
+			return
+		}
 	}
 
+	// Members of the removed groups are removed too when not needed elsewhere
+	groups := make(map[string]string)
+	for _, s := range p {
+		if s.addon.AddonsType == meta {
+			for _, id := range cache.members(s.ID) {
+				if _, ok := groups[id]; !ok {
+					groups[id] = s.ID
+				}
+			}
+		}
+	}
+	for _, id := range cache.orphans(local, p.ids()) {
+		if group, ok := groups[id]; ok {
+			p.remove(local[id], "member of "+group+" not needed anymore")
+		}
+	}
+
+	if err = p.run("Uninstalling " + strings.Join(ids, ", ")); err == nil && !dryRun {
+		if orphans := cache.orphans(local, p.ids()); len(orphans) > 0 {
+			warn("Unneeded dependencies", strings.Join(orphans, ", ")+" are not needed anymore, use \"lxl autoremove\" to remove them")
+		}
+	}
 	return
 }
 
+func autoremove() (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	var p plan
+	for _, id := range cache.orphans(local, nil) {
+		p.remove(local[id], "not needed anymore")
+	}
+
+	if len(p) == 0 {
+		success(current.name, "Nothing to remove")
+		return skip
+	}
+	return p.run("Removing unneeded dependencies")
+}
+
 func install(addonID string) (err error) {
 	// Retrieve manifest
 	manifest, err := fetchManifest()
@@ -118,61 +459,110 @@ func install(addonID string) (err error) {
 	}
 
 	// Finding addon
-	var found *addon
-	for _, item := range manifest.Addons {
-		if item.ID == addonID {
-			found = &item
-			break
-		}
+	found, err := manifest.pick(addonID)
+	if err != nil {
+		return
 	}
-	if found == nil {
-		return fmt.Errorf("Cannot find %s addon", addonID)
+	debug("resolved %s v%s from %s", found.ID, found.Version, found.repo)
+
+	local, err := saved()
+	if err != nil {
+		return
 	}
 
-	// Check for conflicts
-	for _, item := range manifest.Addons {
-		for dep := range found.Conflicts {
-			if item.ID != dep {
-				continue
-			}
+	if err = installPlan(*found, manifest, local).run("Installing " + addonID); err != nil || dryRun {
+		return
+	}
 
-			if path, e := item.dir(); e != nil {
-				return e
-			} else if e := remove(path); !os.IsNotExist(e) {
-				return e
-			}
-		}
+	// Explicitly installed addons are not dependencies anymore
+	return updateStatus(func(l *lxl) error {
+		l.setAuto(found.ID, false)
+		return nil
+	})
+}
+
+func upgrade(ids []string) (err error) {
+	manifest, err := fetchManifest()
+	if err != nil {
+		return
 	}
 
-	// Removing old dependencies
-	for _, dep := range found.Replaces {
-		err = uninstall(dep)
-		if err != nil {
-			return err
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	if len(ids) == 0 {
+		for id := range cache.Installed {
+			ids = append(ids, id)
 		}
+		slices.Sort(ids)
 	}
 
-	// Installing dependencies
-	for _, item := range manifest.Addons {
-		for dep := range found.Dependencies {
-			if item.ID == dep {
-				if err = item.install(); err != nil {
-					return
-				}
+	var p plan
+	for _, id := range ids {
+		r, ok := cache.installed(id)
+		if !ok {
+			return failure(errNotFound, "%s has not been installed by lxl", id)
+		}
+
+		// Sources are checked for newer commits or changes
+		if r.Linked {
+			debug("%s is linked to %s", id, r.Source)
+			continue
+		} else if r.Source != "" {
+			a, e := fromSource(r.Source)
+			if e != nil {
+				warn("Cannot upgrade "+id, e)
+			} else if a.Version == r.Version {
+				debug("%s is already up to date with %s", id, r.Source)
+			} else {
+				a.ID, a.AddonsType = id, r.Type
+				p.remove(local[id], "upgrading to v"+a.Version)
+				p.download(a, "", false)
 			}
+			continue
 		}
+
+		found := manifest.installedFrom(id, r)
+		if found == nil {
+			warn("Cannot upgrade "+id, "it's not available anymore on the subscribed remotes")
+			continue
+		} else if found.Version == r.Version {
+			debug("%s is already up to date", id)
+			continue
+		}
+
+		if a, ok := local[id]; ok {
+			p.remove(a, "upgrading to v"+found.Version)
+		}
+		p = append(p, installPlan(*found, manifest, local)...)
 	}
 
-	// Installing addon
-	return found.install()
+	if len(p) == 0 {
+		success(current.name, "Everything is up to date")
+		return skip
+	}
+	return p.run("Upgrading")
 }
 
 func list(addonID string) (err error) {
-	var list []addon
+	var list, untracked []addon
 	addonID = strings.ToLower(addonID)
 
+	if err = loadStatus(); err != nil {
+		return
+	}
+
 	err = rangeSaved(func(a addon) error {
-		if strings.Contains(a.ID, addonID) {
+		if !strings.Contains(a.ID, addonID) {
+			return nil
+		}
+
+		if id, ok := cache.owner(a); !ok {
+			untracked = append(untracked, a)
+		} else if !slices.ContainsFunc(list, func(item addon) bool { return item.ID == id }) {
+			a.ID = id
 			list = append(list, a)
 		}
 		return nil
@@ -187,19 +577,34 @@ func list(addonID string) (err error) {
 		return
 	}
 
-	for _, item := range manifest.Addons {
-		if item.AddonsType == meta {
+	for i := range list {
+		r, ok := cache.installed(list[i].ID)
+		if !ok {
 			continue
+		} else if item := manifest.installedFrom(list[i].ID, r); item != nil && !r.Linked {
+			list[i] = *item
+			list[i].Version = r.Version
 		}
 
-		for i := range list {
-			if list[i].ID == item.ID {
-				list[i] = item
+		if r.Linked {
+			list[i].link = r.Source
+			if plumbing() {
+				result.Links = append(result.Links, linkReport{ID: list[i].ID, Path: list[i].Path, Target: r.Source})
 			}
 		}
 	}
 
-	return showAddons(os.Args[1], list)
+	if len(list) == 0 && len(untracked) > 0 {
+		showUntracked(untracked)
+		return nil
+	}
+
+	// Meta addons have no files, they are shown on their own section
+	if err = showAddons(current.name, list); err == nil {
+		showGroups(cache.groups(addonID))
+		showUntracked(untracked)
+	}
+	return
 }
 
 func subscribe(repo string) error {
@@ -216,9 +621,39 @@ func unsubscribe(repo string) error {
 	return updateStatus(func(l *lxl) error {
 		if ind := slices.Index(l.Remotes, repo); ind > 0 {
 			l.Remotes = append(l.Remotes[:ind], l.Remotes[ind+1:]...)
+			delete(l.Settings, repo)
+			return nil
+		}
+		return failure(errNotFound, "cannot find %s remote", repo)
+	})
+}
+
+var trustKey, trustSignature string
+
+func trust(repo string, level trustLevel) error {
+	return updateStatus(func(l *lxl) error {
+		evaluated, _, e := evaluate(repo)
+		if e != nil {
+			return e
+		}
+
+		for _, r := range []string{repo, evaluated} {
+			if !slices.Contains(l.Remotes, r) {
+				continue
+			}
+
+			// Official remotes can be verified even if they are always trusted
+			if isOfficial(r) && trustKey == "" {
+				return fmt.Errorf("%s is an official remote and it's always trusted", r)
+			} else if !isOfficial(r) {
+				l.setTrust(r, level)
+			}
+			if trustKey != "" {
+				return l.setKey(r, trustKey, trustSignature)
+			}
 			return nil
 		}
-		return fmt.Errorf("cannot find %s remote", repo)
+		return failure(errNotFound, "cannot find %s remote", repo)
 	})
 }
 
@@ -227,37 +662,61 @@ func remotes(filter string) (err error) {
 		return
 	}
 
+	show := func(r remoteReport) {
+		if !strings.Contains(r.Url, filter) {
+			return
+		} else if plumbing() {
+			result.Remotes = append(result.Remotes, r)
+		} else {
+			fmt.Println(showRemote(r))
+		}
+	}
+
 	switch size := len(cache.Remotes); size {
 	case 0:
-		err = fmt.Errorf("No remote found")
+		err = failure(errNotFound, "No remote found")
 	case 1:
 		success("Found one remote", "details:")
-		fmt.Println(showRemote(cache.Remotes[0]))
+		show(inspectRemote(cache.Remotes[0]))
 	default:
 		success("Found "+strconv.Itoa(size)+" remotes", "List of avaiable remotes:")
 
-		remoteCh := make(chan string, size)
+		remoteCh := make(chan remoteReport, size)
 		for _, u := range cache.Remotes {
 			go func(url string) {
-				remoteCh <- showRemote(url)
+				remoteCh <- inspectRemote(url)
 			}(u)
 		}
 
 		i := 0
-		for screen := range remoteCh {
-			fmt.Println(screen)
+		for r := range remoteCh {
+			show(r)
 			if i++; i == size {
 				close(remoteCh)
 			}
 		}
 	}
 
-	fmt.Print("\n\nYou can manage your remote using the following command:\n add a new remote ")
+	if plumbing() {
+		slices.SortFunc(result.Remotes, func(a, b remoteReport) int {
+			return slices.Index(cache.Remotes, a.Url) - slices.Index(cache.Remotes, b.Url)
+		})
+		return
+	}
+
+	if verbosity < normalLevel {
+		return
+	}
+
+	hint("\n\nYou can manage your remote using the following command:\n add a new remote ")
 	command(" lxl subscribe <remote> ")
-	fmt.Print(" remove a remote  ")
+	hint(" remove a remote  ")
 	command(" lxl unsubscribe <remote> ")
-	fmt.Println()
-	warn("remote will be evaluated", "When adding a new remote the link will be evaluated for performance optimization, therefore original will be lost and then new one will appear on the list\n")
+	hint(" trust a remote   ")
+	command(" lxl trust <remote> ")
+	hint("\n")
+	warn("remote will be evaluated", "When adding a new remote the link will be evaluated for performance optimization, therefore original will be lost and then new one will appear on the list")
+	warn("untrusted remotes", "Addons coming from untrusted remotes are limited to colors and fonts and cannot run post commands\n")
 
 	return
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 	"slices"
 	"strings"
 )
@@ -25,49 +26,70 @@ type manifest struct {
 	Addons  []addon        `json:"addons,omitempty"`
 	Remotes []string       `json:"remotes,omitempty"`
 	LiteXLs []liteXlClient `json:"lite-xls,omitempty"`
+	// Types declares the custom types used by the addons
+	Types []typeInfo `json:"types,omitempty"`
 }
 
 type lxl struct {
-	Remotes []string
-	Path    string
-	*manifest
+	Remotes   []string
+	Path      string
+	Settings  map[string]remoteSettings `toml:",omitempty"`
+	Installed map[string]record         `toml:",omitempty"`
+	// ModVersion of the editor, detected from the lite-xl installation if empty
+	ModVersion string `toml:",omitempty"`
+	// Types are the custom types of the installed addons
+	Types []typeInfo `toml:",omitempty"`
+	// Profile is the name of the profile this status belongs to, empty for the default one
+	Profile string `toml:",omitempty"`
+	// TrustLevels is set once the remotes subscribed before trust levels existed have been migrated
+	TrustLevels bool `toml:",omitempty"`
+	*manifest   `toml:"-"`
 }
 
 var cache *lxl
 
-func (l *lxl) has(reference string) (bool, error) {
+// remotePath parses a reference to a remote, a link or just a part of it like
+// the repository name, optionally followed by the commit specifier
+func remotePath(reference string) (string, error) {
 	var commit string
-	if ind := strings.LastIndexByte(reference, ':'); ind > 0 {
+	if ind := strings.LastIndexByte(reference, ':'); ind > strings.LastIndexByte(reference, '/') {
 		reference, commit = reference[:ind], reference[ind+1:]
 		if commit != "latest" && commit != "last" {
-			return false, fmt.Errorf("Unsupported commit specifier on remote")
+			return "", fmt.Errorf("Unsupported commit specifier on remote")
 		}
 	}
 
-	if u, err := url.Parse(reference); err == nil {
-		reference = u.Path
-	} else {
+	u, err := url.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	return u.Path, nil
+}
+
+func (l *lxl) has(reference string) (bool, error) {
+	path, err := remotePath(reference)
+	if err != nil {
 		return false, err
 	}
 
 	has := slices.ContainsFunc(l.Remotes, func(item string) bool {
-		return strings.Contains(item, reference)
+		return strings.Contains(item, path)
 	})
 	return has, nil
 }
 
-func (l *lxl) add(reference string) (bool, error) {
+func evaluate(reference string) (string, bool, error) {
 	var commit string
-	if ind := strings.LastIndexByte(reference, ':'); ind > 0 {
+	if ind := strings.LastIndexByte(reference, ':'); ind > strings.LastIndexByte(reference, '/') {
 		reference, commit = reference[:ind], reference[ind+1:]
 		if commit != "latest" && commit != "last" {
-			return false, fmt.Errorf("Unsupported commit specifier on remote")
+			return "", false, fmt.Errorf("Unsupported commit specifier on remote")
 		}
 	}
 
 	u, err := url.Parse(reference)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	switch strings.ToLower(u.Host) {
@@ -75,8 +97,18 @@ func (l *lxl) add(reference string) (bool, error) {
 		u.Host = GITHUB_RAW_HOST
 		fallthrough
 	case GITHUB_RAW_HOST:
-		reference = u.String()
-	default:
+		return u.String(), true, nil
+	}
+	return reference, false, nil
+}
+
+func (l *lxl) add(reference string) (bool, error) {
+	reference, known, err := evaluate(reference)
+	if err != nil {
+		return false, err
+	}
+
+	if !known {
 		if raw, e := get(reference); e != nil {
 			return false, fmt.Errorf("Cannot retrieve manifest: %s", e)
 		} else if e = json.Unmarshal(raw, new(manifest)); e != nil {
@@ -84,9 +116,14 @@ func (l *lxl) add(reference string) (bool, error) {
 		}
 	}
 
+	// New remotes are untrusted, the settings tell them from the ones
+	// subscribed before trust levels
 	has := slices.Contains(l.Remotes, reference)
 	if !has {
 		l.Remotes = append(l.Remotes, reference)
+		if !isOfficial(reference) {
+			l.setTrust(reference, untrusted)
+		}
 	}
 	return !has, nil
 }
@@ -95,9 +132,23 @@ func fetchManifestAt(endpoint string) (m *manifest, err error) {
 	m = new(manifest)
 	if raw, e := get(endpoint); e != nil {
 		err = fmt.Errorf("Cannot retrieve manifest from %s: %s", endpoint, e)
+	} else if err = cache.verify(endpoint, endpoint, raw); err != nil {
+		err = fmt.Errorf("Cannot verify manifest from %s: %s", endpoint, err)
 	} else if err = json.Unmarshal(raw, m); err != nil {
 		err = fmt.Errorf("Error while parsing manifest from %s: %s", endpoint, err)
-	} else if len(m.Remotes) > 0 {
+	} else if verbosity >= verboseLevel {
+		for _, i := range validate(endpoint, raw) {
+			debug("%s %s at %s: %s", endpoint, i.Severity, i.Path, i.Message)
+		}
+	}
+
+	if err != nil {
+		return
+	} else if err = m.defineTypes(endpoint); err != nil {
+		return
+	} else if err = m.checkTypes(endpoint); err != nil {
+		return
+	} else if trace("parsed %d addons from %s", len(m.Addons), endpoint); len(m.Remotes) > 0 {
 		newUrls := []string{}
 		for _, r := range m.Remotes {
 			if has, err := cache.has(r); err != nil && !has {
@@ -118,12 +169,20 @@ func fetchManifestAt(endpoint string) (m *manifest, err error) {
 			header += "Found new remotes"
 		}
 
+		if plumbing() || verbosity < normalLevel {
+			result.warning(header, strings.Join(newUrls, " "))
+			for i := range m.Addons {
+				m.Addons[i].repo = endpoint
+			}
+			return
+		}
+
 		success(header, "A remote might contains new addons that would be avaiable to your lxl to find and install. You can add remote via:")
 		for _, u := range newUrls {
-			fmt.Print("  ")
+			hint("  ")
 			command(" lxl subscribe " + u + " ")
 		}
-		fmt.Print("\n\n")
+		hint("\n\n")
 	}
 
 	if m != nil {
@@ -135,51 +194,151 @@ func fetchManifestAt(endpoint string) (m *manifest, err error) {
 	return
 }
 
+// defineTypes registers the custom types declared by the manifest
+func (m *manifest) defineTypes(endpoint string) error {
+	for _, info := range m.Types {
+		if err := defineType(info); err != nil && strictTypes {
+			return failure(errManifest, "%s on %s", err, endpoint)
+		} else if err != nil {
+			warn("Invalid type", err.Error()+" on "+endpoint)
+		} else {
+			trace("type %s defined by %s installed on %s", info.Name, endpoint, info.Folder)
+		}
+	}
+	return nil
+}
+
+// checkTypes reports the addons with an unknown type, they are kept but
+// cannot be installed unless running strict where the whole manifest is invalid
+func (m *manifest) checkTypes(endpoint string) error {
+	for _, a := range m.Addons {
+		if a.AddonsType.known() {
+			continue
+		} else if strictTypes {
+			return failure(errManifest, "Unknown type %s of %s on %s", a.AddonsType, a.ID, endpoint)
+		}
+		warn("Unknown type", a.ID+" has type "+a.AddonsType.String()+" that is not supported, it will not be installed")
+	}
+	return nil
+}
+
 func fetchManifest() (*manifest, error) {
-	if cache != nil {
+	if cache != nil && cache.manifest != nil {
 		return cache.manifest, nil
 	} else if err := loadStatus(); err != nil {
 		return nil, err
 	}
 	size := len(cache.Remotes)
 
-	manifestCh := make(chan *manifest, size)
+	type fetched struct {
+		index int
+		m     *manifest
+	}
+
+	manifestCh := make(chan fetched, size)
 	errorCh := make(chan error, size)
-	for _, u := range cache.Remotes {
-		go func(url string) {
+	for i, u := range cache.Remotes {
+		go func(i int, url string) {
 			m, err := fetchManifestAt(url)
 			if err != nil {
 				errorCh <- err
 			}
-			manifestCh <- m
-		}(u)
+			manifestCh <- fetched{i, m}
+		}(i, u)
 	}
 
-	e := 0
+	e, manifests := 0, make([]*manifest, size)
 	for i := 0; i < size; {
 		select {
 		case err := <-errorCh:
+			debug("remote failed: %s", err)
 			warn("Error with a remote", err)
 			e++
-		case m := <-manifestCh:
+		case f := <-manifestCh:
 			i++
-			if m == nil {
-				continue
-			}
-			if cache.manifest == nil {
-				cache.manifest = m
-				continue
-			}
-			cache.manifest.Addons = append(cache.manifest.Addons, m.Addons...)
+			manifests[f.index] = f.m
 		}
 	}
 	if e == size {
-		return nil, fmt.Errorf("No valid remote")
+		return nil, failure(errNetwork, "No valid remote")
 	}
 	close(errorCh)
 
-	cache.Addons = slices.CompactFunc(cache.Addons, func(a, b addon) bool {
-		return a.ID == b.ID
-	})
+	cache.manifest = merge(manifests)
+	debug("%d addons available from %d remotes", len(cache.Addons), size-e)
+
+	if err := storeManifest(cache.manifest); err != nil {
+		debug("cannot store merged manifest: %s", err)
+	}
 	return cache.manifest, nil
 }
+
+// merge joins the manifests in the order of the remotes so the first ones take
+// precedence, every candidate is kept to allow choosing among them
+func merge(manifests []*manifest) (merged *manifest) {
+	for _, m := range manifests {
+		if m == nil {
+			continue
+		} else if merged == nil {
+			merged = m
+			continue
+		}
+		merged.Addons = append(merged.Addons, m.Addons...)
+		trace("merged %d addons", len(m.Addons))
+	}
+	return
+}
+
+// storeManifest saves the merged manifest so it can be used without fetching
+// the remotes again, for example by the shell completion
+func storeManifest(m *manifest) error {
+	path, err := configPath("lxl", "manifest.json")
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(m)
+	if err == nil {
+		err = os.WriteFile(path, raw, 0666)
+	}
+	return err
+}
+
+func storedManifest() (*manifest, error) {
+	path, err := configPath("lxl", "manifest.json")
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(manifest)
+	return m, json.Unmarshal(raw, m)
+}
+
+func inspectRemote(url string) remoteReport {
+	r, _ := inspect(url)
+	return r
+}
+
+// inspect reports about a remote along with its manifest, nil if unreachable
+func inspect(url string) (r remoteReport, m *manifest) {
+	r = remoteReport{Url: url, Trust: cache.trust(url), Signed: cache.signed(url)}
+
+	m, err := fetchManifestAt(url)
+	if err != nil {
+		r.Error, m = err.Error(), nil
+		return
+	}
+
+	if r.Addons = len(m.Addons); r.Addons > 0 {
+		r.Types = make(map[string]int)
+		for _, a := range m.Addons {
+			r.Types[a.AddonsType.String()]++
+		}
+	}
+	return
+}
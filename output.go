@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type outputFormat uint8
+
+const (
+	textFormat outputFormat = iota
+	jsonFormat
+	tsvFormat
+)
+
+var oFormats = []string{"text", "json", "tsv"}
+
+var format = textFormat
+
+func (f outputFormat) String() string {
+	return oFormats[f]
+}
+
+func parseFormat(s string) (outputFormat, error) {
+	for i := range oFormats {
+		if s == oFormats[i] {
+			return outputFormat(i), nil
+		}
+	}
+	return textFormat, failure(errArgs, "Unrecognized output format: %s", s)
+}
+
+func plumbing() bool {
+	return format != textFormat
+}
+
+// Stable error codes used on machine-readable output
+const (
+	errGeneric    = "generic"
+	errArgs       = "invalid_arguments"
+	errNotFound   = "not_found"
+	errNetwork    = "network"
+	errManifest   = "invalid_manifest"
+	errFilesystem = "filesystem"
+	errForbidden  = "forbidden"
+	errRequired   = "required"
+	errStatus     = "invalid_status"
+	errUnhealthy  = "unhealthy"
+	errLocked     = "lock_mismatch"
+)
+
+type lxlError struct {
+	code string
+	err  error
+}
+
+func (e lxlError) Error() string {
+	return e.err.Error()
+}
+
+func (e lxlError) Unwrap() error {
+	return e.err
+}
+
+func failure(code, model string, v ...any) error {
+	return lxlError{code, fmt.Errorf(model, v...)}
+}
+
+func codeOf(err error) string {
+	var (
+		coded   lxlError
+		pathErr *fs.PathError
+		urlErr  *url.Error
+	)
+
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.As(err, &pathErr):
+		return errFilesystem
+	case errors.As(err, &urlErr):
+		return errNetwork
+	}
+	return errGeneric
+}
+
+type errorReport struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type remoteReport struct {
+	Url    string         `json:"url"`
+	Trust  trustLevel     `json:"trust"`
+	Signed bool           `json:"signed"`
+	Error  string         `json:"error,omitempty"`
+	Addons int            `json:"addons"`
+	Types  map[string]int `json:"types,omitempty"`
+}
+
+type groupReport struct {
+	ID      string   `json:"id"`
+	Version string   `json:"version"`
+	Members []string `json:"members"`
+}
+
+// report is the result of a command on machine-readable formats
+type report struct {
+	Command   string          `json:"command"`
+	Ok        bool            `json:"ok"`
+	Addons    []addon         `json:"addons,omitempty"`
+	Info      []addonInfo     `json:"info,omitempty"`
+	Remotes   []remoteReport  `json:"remotes,omitempty"`
+	Installed []string        `json:"installed,omitempty"`
+	Removed   []string        `json:"removed,omitempty"`
+	Adopted   []string        `json:"adopted,omitempty"`
+	Untracked []addon         `json:"untracked,omitempty"`
+	Groups    []groupReport   `json:"groups,omitempty"`
+	Links     []linkReport    `json:"links,omitempty"`
+	Problems  []problem       `json:"problems,omitempty"`
+	Profiles  []profileReport `json:"profiles,omitempty"`
+	Issues    []issue         `json:"issues,omitempty"`
+	Plan      []step          `json:"plan,omitempty"`
+	Usage     *diskUsage      `json:"usage,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+	Error     *errorReport    `json:"error,omitempty"`
+}
+
+var result report
+
+func (r *report) warning(v ...any) {
+	msg := fmt.Sprint(v[0])
+	if len(v) > 1 {
+		msg += ": " + strings.TrimSpace(fmt.Sprint(v[1:]...))
+	}
+	r.Warnings = append(r.Warnings, msg)
+}
+
+func (r *report) flush(err error) {
+	r.Ok = err == nil || err == skip
+	if !r.Ok {
+		r.Error = &errorReport{Code: codeOf(err), Message: err.Error()}
+	}
+
+	switch format {
+	case jsonFormat:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+	case tsvFormat:
+		for _, a := range r.Addons {
+			row("addon", a.ID, a.AddonsType.String(), a.Version, a.Description)
+		}
+		for _, i := range r.Info {
+			row("addon", i.ID, i.AddonsType.String(), i.Version, i.Description)
+			for _, c := range i.Changes {
+				row("change", c)
+			}
+		}
+		for _, rem := range r.Remotes {
+			status := "ok"
+			if rem.Error != "" {
+				status = "broken"
+			}
+			row("remote", rem.Url, rem.Trust.String(), status, strconv.Itoa(rem.Addons))
+		}
+		for _, s := range r.Plan {
+			row("step", s.Action, s.ID, s.Version, s.Reason, s.Endpoint, s.Path, s.Command, strconv.FormatInt(s.Size, 10))
+		}
+		for _, id := range r.Installed {
+			row("installed", id)
+		}
+		for _, id := range r.Removed {
+			row("removed", id)
+		}
+		for _, id := range r.Adopted {
+			row("adopted", id)
+		}
+		for _, a := range r.Untracked {
+			row("untracked", a.ID, a.AddonsType.String(), a.Path)
+		}
+		for _, g := range r.Groups {
+			row("group", g.ID, g.Version, strings.Join(g.Members, ","))
+		}
+		for _, l := range r.Links {
+			row("link", l.ID, l.Path, l.Target)
+		}
+		for _, p := range r.Problems {
+			row("problem", p.Check, p.Subject, p.Message, strconv.FormatBool(p.Fixed))
+		}
+		for _, p := range r.Profiles {
+			row("profile", p.Name, strconv.FormatBool(p.Active), strconv.Itoa(p.Addons))
+		}
+		for _, i := range r.Issues {
+			row("issue", i.Source, i.Path, i.Severity, i.Message)
+		}
+		for _, w := range r.Warnings {
+			row("warning", w)
+		}
+		if r.Error != nil {
+			row("error", r.Error.Code, r.Error.Message)
+		}
+	}
+}
+
+func row(fields ...string) {
+	for i := range fields {
+		fields[i] = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, fields[i])
+	}
+	fmt.Println(strings.Join(fields, "\t"))
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	removeStep   = "remove"
+	downloadStep = "download"
+	postStep     = "post"
+)
+
+type step struct {
+	Action   string   `json:"action"`
+	ID       string   `json:"id"`
+	Version  string   `json:"version,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Endpoint string   `json:"endpoint,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Command  string   `json:"command,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Size     int64    `json:"size"` // negative when the size cannot be known in advance
+	Auto     bool     `json:"auto,omitempty"`
+	addon    addon
+}
+
+// plan is the ordered list of steps needed to fulfill a command
+type plan []step
+
+type diskUsage struct {
+	Downloaded int64 `json:"downloaded"`
+	Freed      int64 `json:"freed"`
+	Unknown    int   `json:"unknown"`
+}
+
+var dryRun bool
+
+func (p *plan) remove(a addon, reason string) {
+	s := step{Action: removeStep, ID: a.ID, Reason: reason, Path: a.Path, addon: a}
+	if r, ok := cache.installed(a.ID); ok {
+		s.Files = r.files()
+	}
+	*p = append(*p, s)
+}
+
+func (p *plan) download(a addon, reason string, auto bool) {
+	s := step{Action: downloadStep, ID: a.ID, Version: a.Version, Reason: reason, Auto: auto, addon: a}
+	if a.AddonsType != meta {
+		s.Path, _ = a.dir()
+		endpoint, singleton, _ := a.endpoint()
+		ext := path.Ext(endpoint)
+		if a.AddonsType.singleton(a.Path) {
+			singleton, ext = true, path.Ext(a.Path)
+		}
+		if singleton && !strings.HasSuffix(s.Path, ext) {
+			s.Path += ext
+		}
+		s.Endpoint = endpoint
+	}
+	*p = append(*p, s)
+
+	if a.Post != "" {
+		*p = append(*p, step{Action: postStep, ID: a.ID, Command: string(a.Post), addon: a})
+	}
+}
+
+func (p plan) has(action, id string) bool {
+	for _, s := range p {
+		if s.Action == action && s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// installPlan evaluates the steps needed to install an addon with its dependencies
+func installPlan(found addon, m *manifest, local map[string]addon) (p plan) {
+	for id := range found.Conflicts {
+		if a, ok := local[id]; ok {
+			p.remove(a, "conflicts with "+found.ID)
+		}
+	}
+
+	for _, id := range found.Replaces {
+		if a, ok := local[id]; ok && !p.has(removeStep, id) {
+			p.remove(a, "replaced by "+found.ID)
+		}
+	}
+
+	visited := map[string]bool{found.ID: true}
+	var resolve func(a addon)
+	resolve = func(a addon) {
+		for id, dep := range a.Dependencies {
+			if _, ok := local[id]; ok || visited[id] {
+				continue
+			}
+			visited[id] = true
+
+			item := m.find(id)
+			if item == nil {
+				if dep == nil || !dep.Optional {
+					warn("Missing dependency", id+" required by "+a.ID+" cannot be found")
+				}
+				continue
+			}
+
+			debug("%s depends on %s", a.ID, id)
+			resolve(*item)
+			p.download(*item, "dependency of "+a.ID, true)
+		}
+	}
+	resolve(found)
+
+	p.download(found, "", false)
+	return
+}
+
+func (p plan) estimate() (usage diskUsage) {
+	for i := range p {
+		switch s := &p[i]; {
+		case s.addon.AddonsType == meta:
+			// Groups take no space on their own
+			s.Size = 0
+		case s.Action == removeStep:
+			// Artifacts outside the addon folder
+			s.Size = dirSize(s.Path)
+			for _, f := range s.Files {
+				inside := f == s.Path || strings.HasPrefix(f, s.Path+string(filepath.Separator))
+				if size := dirSize(f); !inside && size > 0 && s.Size >= 0 {
+					s.Size += size
+				}
+			}
+			if s.Size >= 0 {
+				usage.Freed += s.Size
+			} else {
+				usage.Unknown++
+			}
+		case s.Action == downloadStep:
+			s.Size = s.addon.downloadSize()
+			if s.Size >= 0 {
+				usage.Downloaded += s.Size
+			} else {
+				usage.Unknown++
+			}
+		}
+	}
+	return
+}
+
+func (a addon) downloadSize() (size int64) {
+	endpoint, singleton, err := a.endpoint()
+	if err != nil || !singleton {
+		return -1
+	}
+
+	if size = headSize(endpoint); size < 0 {
+		return
+	}
+	for _, f := range a.Files {
+		if !f.Arch.supported() {
+			continue
+		} else if s := headSize(f.Url); s >= 0 {
+			size += s
+		} else {
+			return -1
+		}
+	}
+	return
+}
+
+func dirSize(path string) (size int64) {
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return err
+	})
+	if err != nil {
+		return -1
+	}
+	return
+}
+
+// run executes the plan or shows it when running dry
+func (p plan) run(header string) error {
+	return p.runVerified(header, nil)
+}
+
+// runVerified executes the plan refusing it when a downloaded addon does not
+// pass the given check, before anything is removed or installed
+func (p plan) runVerified(header string, verify func(staged) error) error {
+	if dryRun {
+		usage := p.estimate()
+		if plumbing() {
+			result.Plan = append(result.Plan, p...)
+			if result.Usage == nil {
+				result.Usage = new(diskUsage)
+			}
+			result.Usage.Downloaded += usage.Downloaded
+			result.Usage.Freed += usage.Freed
+			result.Usage.Unknown += usage.Unknown
+		} else {
+			p.show(header, usage)
+		}
+		return nil
+	}
+
+	// Everything is downloaded before removing anything so a failure leaves
+	// the installed addons untouched
+	downloads := make(map[int]staged)
+	defer func() {
+		for _, st := range downloads {
+			remove(st.temp)
+		}
+	}()
+	for i, s := range p {
+		if s.Action != downloadStep {
+			continue
+		}
+		if s.Reason != "" {
+			debug("downloading %s, %s", s.ID, s.Reason)
+		}
+
+		st, err := s.addon.fetch()
+		if err != nil {
+			return err
+		}
+		downloads[i] = st
+		if verify != nil {
+			if err = verify(st); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, s := range p {
+		if s.Action != removeStep {
+			continue
+		}
+
+		debug("removing %s at %s", s.ID, s.Path)
+		for _, f := range s.Files {
+			trace("removing %s", f)
+			if err := remove(f); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			prune(filepath.Dir(f))
+		}
+		if err := remove(s.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// Upgrading addons keep their record
+		err := updateStatus(func(l *lxl) error {
+			if !p.has(downloadStep, s.ID) {
+				l.forget(s.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		result.Removed = append(result.Removed, s.ID)
+	}
+
+	// Post commands are executed while placing their addon
+	for i, s := range p {
+		if s.Action != downloadStep {
+			continue
+		} else if err := downloads[i].place(); err != nil {
+			return err
+		}
+
+		if s.Auto {
+			err := updateStatus(func(l *lxl) error {
+				l.setAuto(s.ID, true)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		result.Installed = append(result.Installed, s.ID)
+	}
+	return nil
+}
+
+func (p plan) ids() (list []string) {
+	for _, s := range p {
+		list = append(list, s.ID)
+	}
+	return
+}
+
+func (p plan) describe() (lines []string) {
+	for _, s := range p {
+		line := s.Action + " " + s.ID
+		if s.Version != "" {
+			line += " v" + s.Version
+		}
+		if s.Action == postStep {
+			line += " command " + s.Command
+		}
+		if s.Reason != "" {
+			line += " (" + s.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+func (p plan) String() string {
+	return strings.Join(p.describe(), "\n")
+}
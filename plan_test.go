@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInstallPlan(t *testing.T) {
+	m := &manifest{Addons: []addon{
+		{ID: "a", Version: "1", Dependencies: map[string]*dependency{"b": nil}},
+		{ID: "b", Version: "1", Dependencies: map[string]*dependency{"c": nil}},
+		{ID: "c", Version: "1", Dependencies: map[string]*dependency{"b": nil, "a": nil}},
+	}}
+	local := map[string]addon{}
+
+	p := installPlan(m.Addons[0], m, local)
+	var got []string
+	for _, s := range p {
+		got = append(got, s.Action+" "+s.ID)
+	}
+	want := "[download c download b download a]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("installPlan(a) = %v, want %s", got, want)
+	}
+}
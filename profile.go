@@ -0,0 +1,230 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DEFAULT_PROFILE is the name of the profile in use before switching to another
+const DEFAULT_PROFILE = "default"
+
+type profileReport struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Addons int    `json:"addons"`
+}
+
+var profileRgx = regexp.MustCompile(`^[\w\-]+$`)
+
+func (l *lxl) profile() string {
+	if l.Profile == "" {
+		return DEFAULT_PROFILE
+	}
+	return l.Profile
+}
+
+func profileDir(name string) (string, error) {
+	if !profileRgx.MatchString(name) {
+		return "", failure(errArgs, "Invalid profile name %s, use only letters, numbers, - and _", name)
+	}
+	return configPath("lxl", "profiles", name)
+}
+
+// profileFiles are the paths relative to the config folder that belongs to a
+// profile, the folders of the custom types it has installed included
+func profileFiles(types []typeInfo) []string {
+	list := []string{filepath.Join("lxl", "status.toml"), filepath.Join("lxl", "manifest.json")}
+	for _, t := range installable() {
+		if !slices.Contains(list, t.folder()) {
+			list = append(list, t.folder())
+		}
+	}
+	for _, info := range types {
+		if info.check() == nil && !slices.Contains(list, info.Folder) {
+			list = append(list, info.Folder)
+		}
+	}
+	return list
+}
+
+// move renames the given profile files from a folder to another, returning
+// the ones moved so they can be brought back
+func move(from, to string, files []string) (moved []string, err error) {
+	for _, rel := range files {
+		src, dst := filepath.Join(from, rel), filepath.Join(to, rel)
+		if _, err = os.Stat(src); os.IsNotExist(err) {
+			continue
+		} else if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+			return
+		}
+
+		trace("moving %s to %s", src, dst)
+		if err = os.Rename(src, dst); err != nil {
+			return
+		}
+		moved = append(moved, rel)
+	}
+	return moved, nil
+}
+
+func profiles() (list []profileReport, err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+	list = append(list, profileReport{Name: cache.profile(), Active: true, Addons: len(cache.Installed)})
+
+	dir, err := configPath("lxl", "profiles")
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		var l lxl
+		if _, e := toml.DecodeFile(filepath.Join(dir, entry.Name(), "lxl", "status.toml"), &l); e != nil {
+			debug("cannot read status of profile %s: %s", entry.Name(), e)
+		}
+		list = append(list, profileReport{Name: entry.Name(), Addons: len(l.Installed)})
+	}
+	return
+}
+
+func profile(action, name string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	if action == "list" {
+		list, err := profiles()
+		if err == nil {
+			showProfiles(list)
+		}
+		return err
+	} else if name == "" {
+		return failure(errArgs, "Missing profile name for %s", action)
+	}
+
+	dir, err := profileDir(name)
+	if err != nil {
+		return
+	}
+	_, statErr := os.Stat(dir)
+	exists := statErr == nil || name == cache.profile()
+
+	switch action {
+	case "create":
+		if exists {
+			return failure(errArgs, "Profile %s already exists", name)
+		}
+
+		// A new profile starts with the same remotes but no addon
+		content, e := toml.Marshal(lxl{
+			Remotes:     cache.Remotes,
+			Path:        cache.Path,
+			Settings:    cache.Settings,
+			ModVersion:  cache.ModVersion,
+			Profile:     name,
+			TrustLevels: true,
+		})
+		if e != nil {
+			return e
+		} else if e = os.MkdirAll(filepath.Join(dir, "lxl"), 0750); e != nil {
+			return e
+		} else if e = os.WriteFile(filepath.Join(dir, "lxl", "status.toml"), content, 0666); e != nil {
+			return e
+		}
+		success(current.name, "Profile "+name+" created, use \"lxl profile use "+name+"\" to switch to it")
+	case "use":
+		if name == cache.profile() {
+			success(current.name, "Already using profile "+name)
+			return skip
+		} else if !exists {
+			return failure(errNotFound, "Cannot find profile %s", name)
+		}
+
+		if err = switchProfile(name); err != nil {
+			return
+		}
+		success(current.name, "Switched to profile "+name)
+	case "delete":
+		if name == cache.profile() {
+			return failure(errForbidden, "Cannot delete profile %s while using it", name)
+		} else if !exists {
+			return failure(errNotFound, "Cannot find profile %s", name)
+		}
+
+		if err = os.RemoveAll(dir); err != nil {
+			return
+		}
+		success(current.name, "Profile "+name+" deleted")
+	default:
+		return failure(errArgs, "Unrecognized profile action %s", action)
+	}
+	return skip
+}
+
+// switchProfile stores the addons of the active profile away and puts in
+// place the ones of the given profile
+func switchProfile(name string) error {
+	root, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	from, err := profileDir(cache.profile())
+	if err != nil {
+		return err
+	}
+	to, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+
+	var target lxl
+	if _, err = toml.DecodeFile(filepath.Join(to, "lxl", "status.toml"), &target); err != nil && !os.IsNotExist(err) {
+		return failure(errStatus, "Cannot read status of profile %s: %s", name, err)
+	}
+
+	debug("storing profile %s on %s", cache.profile(), from)
+	stored, err := move(root, from, profileFiles(cache.Types))
+	if err != nil {
+		move(from, root, stored)
+		return failure(errFilesystem, "Cannot store profile %s: %s", cache.profile(), err)
+	}
+
+	debug("restoring profile %s from %s", name, to)
+	restored, err := move(to, root, profileFiles(target.Types))
+	if err != nil {
+		// Going back to the previous profile
+		move(root, to, restored)
+		move(from, root, stored)
+		return failure(errFilesystem, "Cannot restore profile %s: %s", name, err)
+	}
+	cache = nil
+
+	// Anything left is kept on the profile folder
+	os.Remove(filepath.Join(to, "lxl"))
+	if err = os.Remove(to); err != nil {
+		warn("Profile not emptied", to+" still contains files that were not restored")
+	}
+	return nil
+}
+
+func profileNames() (list []string, err error) {
+	profiles, err := profiles()
+	for _, p := range profiles {
+		list = append(list, p.Name)
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// record keeps track of an addon installed by lxl
+type record struct {
+	Version      string     `json:"version"`
+	Type         addonsType `json:"type"`
+	Remote       string     `json:"remote,omitempty" toml:",omitempty"`
+	Source       string     `json:"source,omitempty" toml:",omitempty"` // git repository or local path when installed without a remote
+	Linked       bool       `json:"linked,omitempty" toml:",omitempty"` // symbolic link to the Source
+	Commit       string     `json:"commit,omitempty" toml:",omitempty"` // checked out when installed from a git repository
+	Dependencies []string   `json:"dependencies,omitempty" toml:",omitempty"`
+	Auto         bool       `json:"auto,omitempty" toml:",omitempty"` // installed only as a dependency
+	// Files maps every artifact to its checksum, empty for folders handled as a whole
+	Files map[string]string `json:"files,omitempty" toml:",omitempty"`
+}
+
+func (l *lxl) installed(id string) (r record, ok bool) {
+	r, ok = l.Installed[id]
+	return
+}
+
+func (l *lxl) track(a addon, files map[string]string) {
+	if l.Installed == nil {
+		l.Installed = make(map[string]record)
+	}
+
+	r := record{Version: a.Version, Type: a.AddonsType, Remote: a.repo, Source: a.source, Auto: l.Installed[a.ID].Auto, Files: files}
+	for id, dep := range a.Dependencies {
+		if dep == nil || !dep.Optional {
+			r.Dependencies = append(r.Dependencies, id)
+		}
+	}
+	slices.Sort(r.Dependencies)
+	l.Installed[a.ID] = r
+
+	// Custom types are needed to find their addons again
+	if info := a.AddonsType.info(); a.AddonsType >= builtinTypes {
+		if i := slices.IndexFunc(l.Types, func(t typeInfo) bool { return t.Name == info.Name }); i >= 0 {
+			l.Types[i] = info
+		} else {
+			l.Types = append(l.Types, info)
+		}
+	}
+}
+
+func (l *lxl) setAuto(id string, auto bool) {
+	if r, ok := l.Installed[id]; ok {
+		r.Auto = auto
+		l.Installed[id] = r
+	}
+}
+
+// dependents lists the installed addons that requires the given one
+func (l *lxl) dependents(id string, local map[string]addon) (list []string) {
+	for rid, r := range l.Installed {
+		if _, ok := local[rid]; ok && slices.Contains(r.Dependencies, id) {
+			list = append(list, rid)
+		}
+	}
+	slices.Sort(list)
+	return
+}
+
+// orphans lists the addons installed as dependency that are not needed anymore
+// considering also the ones that are going to be removed
+func (l *lxl) orphans(local map[string]addon, removing []string) (list []string) {
+	gone := func(id string) bool {
+		_, ok := local[id]
+		return !ok || slices.Contains(removing, id) || slices.Contains(list, id)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for id, r := range l.Installed {
+			if !r.Auto || gone(id) {
+				continue
+			}
+
+			needed := slices.ContainsFunc(l.dependents(id, local), func(d string) bool {
+				return !gone(d)
+			})
+			if !needed {
+				list, changed = append(list, id), true
+			}
+		}
+	}
+	slices.Sort(list)
+	return
+}
+
+// members lists the addons grouped by a meta addon, including the
+// dependencies of the members but never the meta addon itself
+func (l *lxl) members(id string) (list []string) {
+	root := id
+	var visit func(id string)
+	visit = func(id string) {
+		for _, dep := range l.Installed[id].Dependencies {
+			if dep != root && !slices.Contains(list, dep) {
+				list = append(list, dep)
+				visit(dep)
+			}
+		}
+	}
+	visit(id)
+	slices.Sort(list)
+	return
+}
+
+// groups lists the installed meta addons matching the given text
+func (l *lxl) groups(text string) (list []groupReport) {
+	for id, r := range l.Installed {
+		if r.Type == meta && strings.Contains(id, text) {
+			list = append(list, groupReport{ID: id, Version: r.Version, Members: r.Dependencies})
+		}
+	}
+	slices.SortFunc(list, func(a, b groupReport) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return
+}
+
+func (l *lxl) forget(id string) {
+	delete(l.Installed, id)
+}
+
+// files lists the artifacts of the addon, sorted with the deepest first
+func (r record) files() (list []string) {
+	for path := range r.Files {
+		list = append(list, path)
+	}
+	slices.SortFunc(list, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return
+}
+
+// saved lists the addons found on the addons folders indexed by ID, the
+// files belonging to an installed addon are indexed by its ID and are never
+// replaced by an untracked file with the same ID
+func saved() (map[string]addon, error) {
+	if err := loadStatus(); err != nil {
+		return nil, err
+	}
+
+	found, owned := make(map[string]addon), make(map[string]bool)
+	err := rangeSaved(func(a addon) error {
+		id, ok := cache.owner(a)
+		if ok {
+			a.ID = id
+		}
+		if _, exists := found[a.ID]; owned[a.ID] || (exists && !ok) {
+			return nil
+		}
+		found[a.ID], owned[a.ID] = a, ok
+		return nil
+	})
+
+	// Meta addons exist only as a record
+	for _, g := range cache.groups("") {
+		if _, ok := found[g.ID]; !ok {
+			found[g.ID] = addon{ID: g.ID, Version: g.Version, AddonsType: meta}
+		}
+	}
+	return found, err
+}
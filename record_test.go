@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	l := &lxl{Installed: map[string]record{
+		"editor":   {Dependencies: []string{"lsp"}},
+		"lsp":      {Auto: true, Dependencies: []string{"json"}},
+		"json":     {Auto: true},
+		"snippets": {Auto: true},
+		"theme":    {Auto: true, Dependencies: []string{"fonts"}},
+		"fonts":    {Auto: true},
+		"missing":  {Auto: true},
+	}}
+	local := map[string]addon{
+		"editor": {}, "lsp": {}, "json": {}, "snippets": {}, "theme": {}, "fonts": {},
+	}
+
+	tests := []struct {
+		name     string
+		removing []string
+		want     []string
+	}{
+		{"unneeded", nil, []string{"fonts", "snippets", "theme"}},
+		{"transitive", []string{"editor"}, []string{"fonts", "json", "lsp", "snippets", "theme"}},
+		{"removing", []string{"lsp", "snippets"}, []string{"fonts", "json", "theme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.orphans(local, tt.removing); !slices.Equal(got, tt.want) {
+				t.Errorf("orphans(%v) = %v, want %v", tt.removing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMembers(t *testing.T) {
+	l := &lxl{Installed: map[string]record{
+		"kit":    {Type: meta, Version: "1.0", Dependencies: []string{"lsp", "theme"}},
+		"tools":  {Type: meta, Version: "2.0", Dependencies: []string{"kit", "git"}},
+		"lsp":    {Dependencies: []string{"json", "widget"}},
+		"json":   {Dependencies: []string{"widget"}},
+		"theme":  {},
+		"git":    {Dependencies: []string{"tools"}},
+		"widget": {},
+	}}
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"kit", []string{"json", "lsp", "theme", "widget"}},
+		{"tools", []string{"git", "json", "kit", "lsp", "theme", "widget"}},
+		{"theme", nil},
+		{"unknown", nil},
+	}
+
+	for _, tt := range tests {
+		if got := l.members(tt.id); !slices.Equal(got, tt.want) {
+			t.Errorf("members(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	groups := l.groups("")
+	if len(groups) != 2 || groups[0].ID != "kit" || groups[1].ID != "tools" {
+		t.Errorf("groups(\"\") = %v, want kit and tools", groups)
+	}
+	if groups := l.groups("oo"); len(groups) != 1 || groups[0].ID != "tools" {
+		t.Errorf("groups(\"oo\") = %v, want tools", groups)
+	}
+}
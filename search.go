@@ -0,0 +1,115 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+type searchFilter struct {
+	kind   string
+	tag    string
+	remote string
+}
+
+var filter searchFilter
+
+func (f searchFilter) match(a addon) bool {
+	if f.kind != "" && !strings.EqualFold(a.AddonsType.String(), f.kind) {
+		return false
+	}
+	if f.remote != "" && !strings.Contains(a.repo, f.remote) {
+		return false
+	}
+	if f.tag != "" && !slices.ContainsFunc(a.Tags, func(t string) bool { return strings.EqualFold(t, f.tag) }) {
+		return false
+	}
+	return true
+}
+
+// fuzzy scores how well the query matches the target, zero means no match
+func fuzzy(query, target string) int {
+	query, target = strings.ToLower(query), strings.ToLower(target)
+
+	switch {
+	case query == "":
+		return 1
+	case target == query:
+		return 100
+	case strings.HasPrefix(target, query):
+		return 80
+	case strings.Contains(target, query):
+		return 60
+	}
+
+	// Looking for the most compact subsequence of the query inside target
+	gaps := -1
+	for start := strings.IndexByte(target, query[0]); start >= 0; {
+		i, j := start, 0
+		for ; i < len(target) && j < len(query); i++ {
+			if target[i] == query[j] {
+				j++
+			}
+		}
+		if g := i - start - len(query); j == len(query) && (gaps < 0 || g < gaps) {
+			gaps = g
+		}
+
+		next := strings.IndexByte(target[start+1:], query[0])
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	if score := 40 - 4*gaps; gaps >= 0 && score > 0 {
+		return score
+	}
+	return 0
+}
+
+func (a addon) score(query string) (best int) {
+	rate := func(weight int, targets ...string) {
+		for _, t := range targets {
+			if s := fuzzy(query, t) * weight; s > best {
+				best = s
+			}
+		}
+	}
+
+	rate(3, a.ID, a.Name)
+	rate(2, a.Provides...)
+	rate(2, a.Tags...)
+
+	// Descriptions are too long to be matched fuzzily
+	if query != "" && strings.Contains(strings.ToLower(a.Description), strings.ToLower(query)) {
+		rate(1, query)
+	}
+	return
+}
+
+// search returns the addons matching query and filter, best ones first
+func search(addons []addon, query string) []addon {
+	var (
+		found  []addon
+		scores = make(map[string]int)
+	)
+
+	for _, a := range addons {
+		if !filter.match(a) {
+			continue
+		}
+		if s := a.score(query); s > 0 {
+			trace("%s scored %d", a.ID, s)
+			scores[a.ID] = s
+			found = append(found, a)
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b addon) int {
+		if d := scores[b.ID] - scores[a.ID]; d != 0 {
+			return d
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return found
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzy(t *testing.T) {
+	tests := []struct {
+		query, target string
+		want          int
+	}{
+		{"", "lsp", 1},
+		{"lsp", "lsp", 100},
+		{"LSP", "lsp", 100},
+		{"lsp", "lspkind", 80},
+		{"lsp", "snippets-lsp", 60},
+		{"lsp", "lxsp", 36},
+		{"lsp", "l_s_p", 32},
+		{"lsp", "long stop", 16},
+		{"lsp", "ls", 0},
+		{"lsp", "spl", 0},
+		{"lsp", "l-----------s-p", 0},
+	}
+
+	for _, tt := range tests {
+		if got := fuzzy(tt.query, tt.target); got != tt.want {
+			t.Errorf("fuzzy(%q, %q) = %d, want %d", tt.query, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	addons := []addon{
+		{ID: "autocomplete", Description: "complete words"},
+		{ID: "lsp", Tags: []string{"language"}},
+		{ID: "lspkind", AddonsType: color},
+		{ID: "snippets", Provides: []string{"lsp-snippets"}},
+		{ID: "gitdiff", Description: "show lsp diagnostics"},
+		{ID: "minimap"},
+		{ID: "alpha", Name: "LSP helper", AddonsType: color},
+	}
+
+	tests := []struct {
+		query  string
+		filter searchFilter
+		want   []string
+	}{
+		{"lsp", searchFilter{}, []string{"lsp", "alpha", "lspkind", "snippets", "gitdiff"}},
+		{"lsp", searchFilter{kind: "COLOR"}, []string{"alpha", "lspkind"}},
+		{"", searchFilter{tag: "Language"}, []string{"lsp"}},
+		{"cmp", searchFilter{}, []string{"autocomplete"}},
+		{"words", searchFilter{}, []string{"autocomplete"}},
+		{"zzz", searchFilter{}, nil},
+	}
+
+	defer func(old searchFilter) { filter = old }(filter)
+	for _, tt := range tests {
+		filter = tt.filter
+		var got []string
+		for _, a := range search(addons, tt.query) {
+			got = append(got, a.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("search(%q, %+v) = %v, want %v", tt.query, tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sourceType forces the type of the addons installed from a source
+var sourceType string
+
+// isLink checks if the source is a link rather than a local path
+func isLink(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// isSource checks if the argument is a git repository or a local path instead
+// of an addon specifier, links to a subscribed remote followed by an ID are not
+func isSource(arg string) bool {
+	if s := parseSpecifier(arg); s.Remote != "" && cache != nil {
+		if has, _ := cache.has(s.Remote); has {
+			return false
+		}
+	}
+	return isLink(arg) || filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") || strings.ContainsRune(arg, filepath.Separator)
+}
+
+// idOf is the ID of an addon given the name of its file or folder
+func idOf(name string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(name, filepath.Ext(name)), "lite-xl-")
+}
+
+// inferType guesses the type of an addon from its name
+func inferType(name string) addonsType {
+	name = strings.ToLower(name)
+	switch {
+	case font.singleton(name):
+		return font
+	case strings.HasPrefix(name, "language_"):
+		return language
+	case strings.HasPrefix(name, "lsp_"):
+		return lsp
+	case strings.Contains(name, "color") || strings.Contains(name, "theme"):
+		return color
+	}
+	return plugin
+}
+
+// fromSource describes the addon found on a git repository, optionally
+// followed by :ref, or on a local file or folder. The version is the commit
+// for repositories and the digest of the content for local paths
+func fromSource(source string) (a addon, err error) {
+	var name string
+	if isLink(source) {
+		repo, ref := "", ""
+		if repo, name, ref, err = extract(source); err != nil {
+			return a, failure(errArgs, "%s", err)
+		} else if ref == "" {
+			ref = "HEAD"
+		}
+
+		commit, e := lsRemote(repo, ref)
+		if e != nil {
+			return a, e
+		}
+		a.Url, a.Version = repo+":"+commit, commit[:7]
+		name = strings.TrimSuffix(name, ".git")
+	} else {
+		if source, err = filepath.Abs(source); err != nil {
+			return
+		}
+
+		info, e := os.Stat(source)
+		if e != nil {
+			return a, failure(errNotFound, "Cannot find %s", source)
+		} else if a.Version, err = digest(source); err != nil {
+			return
+		}
+		a.Url, name = source, info.Name()
+	}
+
+	a.AddonsType = inferType(name)
+	if sourceType != "" {
+		a.AddonsType = registerType(sourceType)
+	}
+	a.ID, a.source = idOf(name), source
+	return
+}
+
+// digest is a short checksum of the relevant files of a local path
+func digest(path string) (string, error) {
+	var content []byte
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if p != path && !isRelevant(d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if d.IsDir() {
+			return nil
+		}
+
+		s, err := checksum(p)
+		rel, _ := filepath.Rel(path, p)
+		content = append(content, filepath.ToSlash(rel)+":"+s+"\n"...)
+		return err
+	})
+	return sum(content)[:7], err
+}
+
+// read retrieves the content of a link or of a local file
+func read(endpoint string) ([]byte, error) {
+	if isLink(endpoint) {
+		return get(endpoint)
+	}
+	return os.ReadFile(endpoint)
+}
+
+// checkout puts the content of a repository or of a local folder on a
+// temporary folder
+func checkout(endpoint string) (string, error) {
+	if isLink(endpoint) {
+		return clone(endpoint, "")
+	}
+
+	path, err := os.MkdirTemp("", TEMP_PREFIX+filepath.Base(endpoint))
+	if err != nil {
+		return "", err
+	}
+
+	debug("copying %s into %s", endpoint, path)
+	err = filepath.WalkDir(endpoint, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(endpoint, p)
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(filepath.Join(path, rel), 0750)
+		}
+		return copyFile(p, filepath.Join(path, rel))
+	})
+	if err != nil {
+		remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// installSource installs an addon that is not on any remote
+func installSource(source string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	}
+
+	a, err := fromSource(source)
+	if err != nil {
+		return
+	}
+	debug("resolved %s v%s of type %s from %s", a.ID, a.Version, a.AddonsType, a.source)
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	// Only the addons installed from the same source can be replaced, they
+	// keep their type unless another one is given
+	var p plan
+	if found, ok := local[a.ID]; ok {
+		r, _ := cache.installed(a.ID)
+		if r.Source != a.source {
+			return failure(errForbidden, "%s is already installed, uninstall it before installing it from %s", a.ID, a.source)
+		} else if sourceType == "" {
+			a.AddonsType = r.Type
+		}
+		p.remove(found, "reinstalling from "+a.source)
+	}
+	p.download(a, "", false)
+
+	if err = p.run("Installing " + source); err != nil || dryRun {
+		return
+	}
+	return updateStatus(func(l *lxl) error {
+		l.setAuto(a.ID, false)
+		return nil
+	})
+}
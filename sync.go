@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PROJECT_FILE is the default declarative addon set read by sync
+const PROJECT_FILE = "lxl.toml"
+
+// wanted is an addon required by the project file, it can be given as a
+// version constraint only or as a table
+type wanted struct {
+	Version string `toml:"version"`
+	Pin     string `toml:"pin"`
+	Remote  string `toml:"remote"`
+	lenient bool   // a different version is installed with a warning
+	auto    bool   // installed as a dependency
+}
+
+func (w *wanted) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		w.Version = v
+	case map[string]any:
+		for key, value := range v {
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("Expected a string for %s", key)
+			}
+			switch key {
+			case "version":
+				w.Version = s
+			case "pin":
+				w.Pin = s
+			case "remote":
+				w.Remote = s
+			default:
+				return fmt.Errorf("Unknown field %s", key)
+			}
+		}
+	default:
+		return fmt.Errorf("Expected a version constraint or a table")
+	}
+	return nil
+}
+
+func (w wanted) constraints() (constraints, error) {
+	list, err := parseConstraints(w.Version)
+	if err == nil && w.Pin != "" {
+		var pin constraints
+		if pin, err = parseConstraints("==" + w.Pin); err == nil {
+			list = append(list, pin...)
+		}
+	}
+	return list, err
+}
+
+type project struct {
+	Remotes []string          `toml:"remotes"`
+	Addons  map[string]wanted `toml:"addons"`
+}
+
+var (
+	projectFile string
+	pruneSync   bool
+)
+
+func loadProject(path string) (p project, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, failure(errNotFound, "Cannot find %s", path)
+		}
+		return p, failure(errFilesystem, "Cannot read %s: %s", path, err)
+	}
+
+	if err = toml.Unmarshal(content, &p); err != nil {
+		err = failure(errManifest, "Cannot parse %s: %s", path, err)
+	}
+	return
+}
+
+// subscribeAll adds the remotes that are not subscribed yet, without saving
+// them when running dry
+func subscribeAll(remotes []string) (err error) {
+	added := false
+	for _, r := range remotes {
+		var ok bool
+		if ok, err = cache.add(r); err != nil {
+			return fmt.Errorf("Cannot subscribe to %s: %w", r, err)
+		} else if ok {
+			debug("subscribed to %s", r)
+			added = true
+		}
+	}
+
+	if added {
+		cache.manifest = nil
+		if !dryRun {
+			err = saveStatus()
+		}
+	}
+	return
+}
+
+// candidate finds the addon matching what the project wants, remotes caches
+// the manifests of the remotes explicitly required
+func (w wanted) candidate(id string, m *manifest, remotes map[string]*manifest) (*addon, error) {
+	if w.Remote != "" {
+		endpoint, _, err := evaluate(w.Remote)
+		if err != nil {
+			return nil, err
+		}
+		if remotes[endpoint] == nil {
+			if remotes[endpoint], err = fetchManifestAt(endpoint); err != nil {
+				return nil, failure(errNetwork, "%s", err)
+			}
+		}
+		m = remotes[endpoint]
+	}
+
+	found := m.find(id)
+	if found == nil {
+		return nil, failure(errNotFound, "Cannot find %s addon", id)
+	}
+
+	list, err := w.constraints()
+	if err != nil {
+		return nil, failure(errArgs, "Invalid version of %s: %s", id, err)
+	} else if match, _ := m.choose(id, "", list); match != nil {
+		return match, nil
+	} else if !w.lenient {
+		return nil, failure(errNotFound, "%s v%s does not satisfy %s", id, found.Version, w)
+	}
+	warn("Different version of "+id, w.String()+" is not available, v"+found.Version+" will be installed")
+	return found, nil
+}
+
+func (w wanted) String() string {
+	switch {
+	case w.Pin == "":
+		return w.Version
+	case w.Version == "":
+		return "==" + w.Pin
+	}
+	return w.Version + ", ==" + w.Pin
+}
+
+func syncProject() error {
+	proj, err := loadProject(projectFile)
+	if err != nil {
+		return err
+	}
+	return proj.apply(projectFile)
+}
+
+// apply installs, upgrades or downgrades the addons to match the project,
+// source is the file it has been read from
+func (proj project) apply(source string) (err error) {
+	if err = loadStatus(); err != nil {
+		return
+	} else if err = subscribeAll(proj.Remotes); err != nil {
+		return
+	}
+
+	remotes := make(map[string]*manifest)
+	manifest, err := fetchManifest()
+	if err != nil {
+		return
+	}
+
+	local, err := saved()
+	if err != nil {
+		return
+	}
+
+	ids := make([]string, 0, len(proj.Addons))
+	for id := range proj.Addons {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	// Resolve everything before touching the disk
+	found := make(map[string]addon, len(ids))
+	for _, id := range ids {
+		a, err := proj.Addons[id].candidate(id, manifest, remotes)
+		if err != nil {
+			return err
+		}
+		found[id] = *a
+	}
+
+	// Dependencies of the wanted addons are kept when pruning
+	needed := make(map[string]bool)
+	var require func(a addon)
+	require = func(a addon) {
+		if needed[a.ID] {
+			return
+		}
+		needed[a.ID] = true
+		for id := range a.Dependencies {
+			if dep := manifest.find(id); dep != nil {
+				require(*dep)
+			} else {
+				needed[id] = true
+			}
+		}
+	}
+	for _, a := range found {
+		require(a)
+	}
+
+	var p plan
+	if pruneSync {
+		var extra []string
+		for id := range cache.Installed {
+			if _, ok := local[id]; ok && !needed[id] {
+				extra = append(extra, id)
+			}
+		}
+		slices.Sort(extra)
+		for _, id := range extra {
+			p.remove(local[id], "not listed on "+source)
+			delete(local, id)
+		}
+	}
+
+	for _, id := range ids {
+		a := found[id]
+		if p.has(downloadStep, id) {
+			debug("%s is already required as dependency", id)
+			continue
+		} else if _, ok := local[id]; !ok {
+			p = append(p, installPlan(a, manifest, local)...)
+		} else if r, ok := cache.installed(id); !ok {
+			warn("Cannot sync "+id, "it has not been installed by lxl, use \"lxl adopt\" to manage it")
+			continue
+		} else if list, _ := proj.Addons[id].constraints(); list.match(r.Version) || r.Version == a.Version {
+			debug("%s v%s already satisfies %s", id, r.Version, proj.Addons[id])
+			continue
+		} else {
+			reason := "downgrading to v" + a.Version
+			if compareVersions(a.Version, r.Version) > 0 {
+				reason = "upgrading to v" + a.Version
+			}
+			p.remove(local[id], reason)
+			p = append(p, installPlan(a, manifest, local)...)
+		}
+
+		// Shared dependencies are downloaded only once
+		for _, s := range p {
+			if s.Action == downloadStep {
+				local[s.ID] = s.addon
+			}
+		}
+	}
+
+	if len(p) == 0 {
+		success(current.name, "Everything is in sync with "+source)
+	} else if err = p.run("Syncing with " + source); err != nil {
+		return
+	}
+	if dryRun {
+		if len(p) == 0 {
+			err = skip
+		}
+		return
+	}
+
+	// Listed addons are not dependencies anymore
+	err = updateStatus(func(l *lxl) error {
+		for _, id := range ids {
+			l.setAuto(id, proj.Addons[id].auto)
+		}
+		return nil
+	})
+	if err == nil && len(p) == 0 {
+		err = skip
+	}
+	return
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type trustLevel uint8
+
+const (
+	untrusted trustLevel = iota
+	trusted
+	official
+)
+
+var tLevels = []string{"untrusted", "trusted", "official"}
+
+func (l trustLevel) String() string {
+	return tLevels[l]
+}
+
+func (l trustLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *trustLevel) UnmarshalText(b []byte) error {
+	for i := range tLevels {
+		if string(b) == tLevels[i] {
+			*l = trustLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unrecognized trust level: %s", b)
+}
+
+// remoteSettings are the per remote options stored on status.toml
+type remoteSettings struct {
+	Trust     trustLevel
+	PublicKey string `toml:",omitempty"`
+	Signature string `toml:",omitempty"`
+}
+
+func isOfficial(remote string) bool {
+	return strings.HasPrefix(remote, BASE_ENDPOINT)
+}
+
+func (l *lxl) trust(remote string) trustLevel {
+	if isOfficial(remote) {
+		return official
+	}
+
+	// Only the official remotes can be official
+	if s, ok := l.Settings[remote]; ok && s.Trust != untrusted {
+		return trusted
+	}
+	return untrusted
+}
+
+func (l *lxl) setTrust(remote string, level trustLevel) {
+	if l.Settings == nil {
+		l.Settings = make(map[string]remoteSettings)
+	}
+
+	s := l.Settings[remote]
+	s.Trust = level
+	l.Settings[remote] = s
+}
+
+// setKey configures the public key verifying the manifest of a remote and
+// optionally the link of its signature
+func (l *lxl) setKey(remote, publicKey, signature string) error {
+	if key, err := decodeKey(publicKey); err != nil {
+		return failure(errArgs, "Invalid public key: %s", err)
+	} else if len(key) != ed25519.PublicKeySize {
+		return failure(errArgs, "Invalid public key size: %d", len(key))
+	}
+
+	if l.Settings == nil {
+		l.Settings = make(map[string]remoteSettings)
+	}
+	s := l.Settings[remote]
+	s.PublicKey, s.Signature = strings.TrimSpace(publicKey), signature
+	l.Settings[remote] = s
+	return nil
+}
+
+// migrateTrust trusts the remotes subscribed before trust levels existed, they
+// are the only ones without settings and are kept working as they were. It
+// runs only once, remotes added later without settings stay untrusted
+func (l *lxl) migrateTrust() (migrated []string) {
+	if l.TrustLevels {
+		return
+	}
+	l.TrustLevels = true
+
+	for _, r := range l.Remotes {
+		if _, ok := l.Settings[r]; !ok && !isOfficial(r) {
+			l.setTrust(r, trusted)
+			migrated = append(migrated, r)
+		}
+	}
+	return
+}
+
+func (l *lxl) signed(remote string) bool {
+	s, ok := l.Settings[remote]
+	return ok && s.PublicKey != ""
+}
+
+// verify checks the detached ed25519 signature of a remote manifest when
+// a public key has been configured for it, at is the link the content has
+// been retrieved from and the signature is looked for next to it
+func (l *lxl) verify(remote, at string, content []byte) error {
+	if !l.signed(remote) {
+		return nil
+	}
+	s := l.Settings[remote]
+
+	key, err := decodeKey(s.PublicKey)
+	if err != nil {
+		return fmt.Errorf("Invalid public key: %s", err)
+	} else if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("Invalid public key size: %d", len(key))
+	}
+
+	endpoint := s.Signature
+	if endpoint == "" {
+		endpoint = at + ".sig"
+	}
+
+	raw, err := get(endpoint)
+	if err != nil {
+		return fmt.Errorf("Cannot retrieve signature: %s", err)
+	}
+
+	sig := raw
+	if len(raw) != ed25519.SignatureSize {
+		if sig, err = decodeKey(string(raw)); err != nil {
+			return fmt.Errorf("Invalid signature: %s", err)
+		}
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), content, sig) {
+		return fmt.Errorf("Signature mismatch")
+	}
+	return nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func (t addonsType) executable() bool {
+	switch t {
+	case font, color:
+		return false
+	}
+	return true
+}
+
+// allowed checks if the addon can be installed considering the trust level
+// of the remote it comes from
+func (a addon) allowed() error {
+	// Sources are given explicitly by the user
+	if cache == nil || a.source != "" || cache.trust(a.repo) != untrusted {
+		return nil
+	}
+
+	if a.Post != "" {
+		return failure(errForbidden, "%s comes from an untrusted remote and cannot run post commands", a.ID)
+	}
+	if a.AddonsType.executable() {
+		return failure(errForbidden, "%s comes from an untrusted remote and is a %s, only colors and fonts are allowed", a.ID, a.AddonsType)
+	}
+	return nil
+}
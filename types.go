@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type addonsType uint8
+
+const (
+	plugin addonsType = iota
+	font
+	library
+	color
+	meta
+	language
+	lsp
+	bundle
+)
+
+// typeInfo describes where and how the addons of a type are installed
+type typeInfo struct {
+	Name   string `json:"name"`
+	Folder string `json:"folder"`
+	// Extensions of the files installed as they are, the addons without one
+	// of them are handled as folders
+	Extensions []string `json:"extensions,omitempty"`
+	known      bool
+}
+
+var luaFiles = []string{".lua"}
+
+// aTypes is the registry of types indexed by addonsType, types found on
+// manifests but never declared are kept as unknown so they are preserved.
+// Languages, lsp and bundles share the plugins folder since they are plugins
+// for lite-xl that loads them only from there, their types just tell what
+// they provide and whether they are installed as folders
+var (
+	aTypes = []typeInfo{
+		{Name: "plugin", Folder: "plugins", Extensions: luaFiles, known: true},
+		{Name: "font", Folder: "fonts", Extensions: []string{".ttf", ".otf", ".ttc"}, known: true},
+		{Name: "library", Folder: "libraries", Extensions: luaFiles, known: true},
+		{Name: "color", Folder: "colors", Extensions: luaFiles, known: true},
+		{Name: "meta", known: true},
+		{Name: "language", Folder: "plugins", Extensions: luaFiles, known: true},
+		{Name: "lsp", Folder: "plugins", Extensions: luaFiles, known: true},
+		{Name: "bundle", Folder: "plugins", known: true},
+	}
+	typesMu sync.RWMutex
+)
+
+const builtinTypes = 8
+
+// strictTypes makes manifests containing unknown types invalid
+var strictTypes bool
+
+func (t addonsType) info() typeInfo {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return aTypes[t]
+}
+
+func (t addonsType) String() string {
+	return t.info().Name
+}
+
+func (t addonsType) known() bool {
+	return t.info().known
+}
+
+func (t addonsType) folder() string {
+	return t.info().Folder
+}
+
+// singleton checks if the given file can be installed as it is
+func (t addonsType) singleton(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext != "" && slices.Contains(t.info().Extensions, ext)
+}
+
+// installable lists the known types saved on their own folder, only the
+// first type is given for folders shared by more types
+func installable() (list []addonsType) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	var folders []string
+	for i, info := range aTypes {
+		if info.known && info.Folder != "" && !slices.Contains(folders, info.Folder) {
+			folders = append(folders, info.Folder)
+			list = append(list, addonsType(i))
+		}
+	}
+	return
+}
+
+// typeNames lists every type name, the builtin first
+func typeNames() (names []string) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	for _, info := range aTypes {
+		names = append(names, info.Name)
+	}
+	return
+}
+
+func registerType(name string) addonsType {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	for i := range aTypes {
+		if aTypes[i].Name == name {
+			return addonsType(i)
+		}
+	}
+	aTypes = append(aTypes, typeInfo{Name: name})
+	return addonsType(len(aTypes) - 1)
+}
+
+func (info typeInfo) check() error {
+	switch {
+	case info.Name == "":
+		return fmt.Errorf("Missing type name")
+	case info.Folder == "":
+		return fmt.Errorf("Missing folder of type %s", info.Name)
+	case !filepath.IsLocal(info.Folder) || strings.ContainsAny(info.Folder, `/\`) || info.Folder == "." || info.Folder == "lxl":
+		return fmt.Errorf("Invalid folder %s of type %s, expected the name of a subfolder", info.Folder, info.Name)
+	}
+
+	for _, ext := range info.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("Invalid extension %s of type %s", ext, info.Name)
+		}
+	}
+	return nil
+}
+
+// defineType registers a custom type, builtin types cannot be redefined
+func defineType(info typeInfo) error {
+	if err := info.check(); err != nil {
+		return err
+	}
+
+	t := registerType(info.Name)
+	if t < builtinTypes {
+		return fmt.Errorf("Type %s is builtin and cannot be redefined", info.Name)
+	}
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if old := aTypes[t]; old.known && (old.Folder != info.Folder || !slices.Equal(old.Extensions, info.Extensions)) {
+		return fmt.Errorf("Type %s is already defined with folder %s", info.Name, old.Folder)
+	}
+	info.known = true
+	aTypes[t] = info
+	return nil
+}
+
+func (t addonsType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t addonsType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *addonsType) UnmarshalText(b []byte) error {
+	return t.UnmarshalJSON([]byte(strconv.Quote(string(b))))
+}
+
+func (t *addonsType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	} else if s == "" {
+		return fmt.Errorf("Empty addon type")
+	}
+
+	*t = registerType(s)
+	return nil
+}
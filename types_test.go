@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestTypeInfoCheck(t *testing.T) {
+	tests := []struct {
+		info  typeInfo
+		valid bool
+	}{
+		{typeInfo{Name: "snippet", Folder: "snippets", Extensions: []string{".json"}}, true},
+		{typeInfo{Name: "theme", Folder: "themes"}, true},
+		{typeInfo{Folder: "snippets"}, false},
+		{typeInfo{Name: "snippet"}, false},
+		{typeInfo{Name: "snippet", Folder: "."}, false},
+		{typeInfo{Name: "snippet", Folder: ".."}, false},
+		{typeInfo{Name: "snippet", Folder: "../snippets"}, false},
+		{typeInfo{Name: "snippet", Folder: "/snippets"}, false},
+		{typeInfo{Name: "snippet", Folder: "a/snippets"}, false},
+		{typeInfo{Name: "snippet", Folder: `a\snippets`}, false},
+		{typeInfo{Name: "snippet", Folder: "snippets/"}, false},
+		{typeInfo{Name: "snippet", Folder: "lxl"}, false},
+		{typeInfo{Name: "snippet", Folder: "snippets", Extensions: []string{"json"}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.info.check(); (err == nil) != tt.valid {
+			t.Errorf("%+v check() = %v, want valid %t", tt.info, err, tt.valid)
+		}
+	}
+}
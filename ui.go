@@ -3,46 +3,97 @@ package main
 import (
 	"fmt"
 	"github.com/DazFather/brush"
+	"io"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-const USAGE = `Usage:
- lxl <install|uninstall|find|list> <pluginID>
- lxl <subscribe|unsubscribe|remotes> <remote>`
-
 // Palette
 var (
-	warn    = newPrinter(brush.Yellow, " ! ")
-	danger  = newPrinter(brush.Red, " X ")
-	success = newPrinter(brush.Green, " v ")
-	command = newPrinter(brush.Black, " $ ")
+	warn    = collected(newPrinter(brush.Yellow, " ! ", quietLevel))
+	danger  = newPrinter(brush.Red, " X ", quietLevel)
+	success = newPrinter(brush.Green, " v ", normalLevel)
+	command = newPrinter(brush.Black, " $ ", quietLevel)
 )
 
-func newPrinter(baseTone brush.ANSIColor, prefix string) func(...any) {
+// display is where messages are printed, the standard error when the
+// standard output is taken by what the command produces
+var display io.Writer = os.Stdout
+
+func newPrinter(baseTone brush.ANSIColor, prefix string, level logLevel) func(...any) {
 	white := brush.New(brush.BrightWhite, brush.UseColor(baseTone))
 	black := brush.New(brush.Black, brush.UseColor(baseTone+8))
 
 	return func(v ...any) {
+		if plumbing() || verbosity < level {
+			return
+		}
+
 		var suffix string = "\n"
 		if len(v) >= 2 {
 			suffix = brush.Paintln(baseTone, nil, v[1:]...).String()
 			v[0] = fmt.Sprint(" ", v[0], " ")
 			v = v[0:1]
 		}
-		fmt.Printf("%s%s %s", white.Paint(prefix), black.Paint(v...), suffix)
+		fmt.Fprintf(display, "%s%s %s", white.Paint(prefix), black.Paint(v...), suffix)
+	}
+}
+
+// collected makes the printer report on the result when on plumbing mode
+func collected(print func(...any)) func(...any) {
+	return func(v ...any) {
+		if plumbing() {
+			result.warning(v...)
+		} else {
+			print(v...)
+		}
+	}
+}
+
+type typeStyle struct {
+	letter string
+	color  brush.ANSIColor
+}
+
+// typeStyles of the builtin types, the custom ones use their initial
+var typeStyles = map[addonsType]typeStyle{
+	plugin:   {"P", brush.BrightRed},
+	font:     {"F", brush.BrightGreen},
+	library:  {"L", brush.BrightYellow},
+	color:    {"C", brush.BrightBlue},
+	meta:     {"M", brush.BrightMagenta},
+	language: {"G", brush.BrightCyan},
+	lsp:      {"S", brush.Cyan},
+	bundle:   {"B", brush.Magenta},
+}
+
+func (t addonsType) style() typeStyle {
+	if s, ok := typeStyles[t]; ok {
+		return s
 	}
+	return typeStyle{strings.ToUpper(t.String()[:1]), brush.BrightBlack}
 }
 
 func (t addonsType) color() brush.ANSIColor {
-	return brush.ANSIColor(t) + 9
+	return t.style().color
 }
 
 func (t addonsType) icon() brush.Painted {
-	s := t.String()
-	ch := " " + strings.ToUpper(s[:1]) + " "
+	return brush.Paint(brush.BrightWhite, brush.UseColor(t.color()), " ", t.style().letter, " ")
+}
 
-	return brush.Paint(brush.BrightWhite, brush.UseColor(t.color()), ch)
+func (l trustLevel) badge() brush.Painted {
+	tone := brush.Yellow
+	switch l {
+	case official:
+		tone = brush.Green
+	case trusted:
+		tone = brush.Cyan
+	}
+
+	return brush.Paint(brush.BrightWhite, brush.UseColor(tone), " ", strings.ToUpper(l.String()), " ")
 }
 
 func (a addon) snippet(maxDesc int) brush.Highlighted {
@@ -53,6 +104,14 @@ func (a addon) snippet(maxDesc int) brush.Highlighted {
 
 	color := a.AddonsType.color()
 
+	if a.link != "" {
+		return brush.Join(
+			a.AddonsType.icon(),
+			brush.Paint(color, nil, " ", a.ID),
+			"\t"+desc,
+			brush.Paint(brush.BrightBlack, nil, " (linked to ", a.link, ")"),
+		)
+	}
 	return brush.Join(
 		a.AddonsType.icon(),
 		brush.Paint(color, nil, " ", a.ID),
@@ -74,9 +133,14 @@ func (a addon) showcase() {
 }
 
 func showAddons(header string, addons []addon) error {
+	if len(addons) == 0 {
+		return failure(errNotFound, "Cannot find any addon")
+	} else if plumbing() {
+		result.Addons = addons
+		return nil
+	}
+
 	switch n := len(addons); n {
-	case 0:
-		return fmt.Errorf("Cannot find any addon")
 	case 1:
 		success(header, "Found 1 matching addon")
 		addons[0].showcase()
@@ -91,35 +155,28 @@ func showAddons(header string, addons []addon) error {
 	return nil
 }
 
-func showRemote(url string) string {
+func showRemote(r remoteReport) string {
 	var screen = new(strings.Builder)
 
 	screen.WriteString(" > ")
 
-	m, err := fetchManifestAt(url)
-	if err != nil {
+	if r.Error != "" {
 		screen.WriteString(brush.Paint(brush.BrightWhite, brush.UseColor(brush.Red), " BROKEN ").String())
 		screen.WriteByte(' ')
 	}
 
-	if strings.HasPrefix(url, "https://"+GITHUB_RAW_HOST+"/lite-xl/") {
-		screen.WriteString(brush.Paint(
-			brush.BrightWhite,
-			brush.UseColor(brush.Green),
-			" OFFICIAL ",
-		).String())
+	screen.WriteString(r.Trust.badge().String())
+	screen.WriteByte(' ')
 
+	if r.Signed {
+		screen.WriteString(brush.Paint(brush.BrightWhite, brush.UseColor(brush.Blue), " SIGNED ").String())
 		screen.WriteByte(' ')
 	}
 
-	if len(m.Addons) > 0 {
-		screen.WriteString(brush.Paint(brush.Black, brush.UseColor(brush.BrightWhite), " ", len(m.Addons), " ADDONS ").String())
-		counter := make([]int, len(aTypes))
-		for _, a := range m.Addons {
-			counter[a.AddonsType]++
-		}
-		for t, c := range counter {
-			if c != 0 {
+	if r.Addons > 0 {
+		screen.WriteString(brush.Paint(brush.Black, brush.UseColor(brush.BrightWhite), " ", r.Addons, " ADDONS ").String())
+		for t, name := range typeNames() {
+			if c := r.Types[name]; c != 0 {
 				icon := addonsType(t).icon()
 				screen.WriteString(icon.Append(strconv.Itoa(c) + " ").String())
 			}
@@ -127,7 +184,251 @@ func showRemote(url string) string {
 		screen.WriteString("\n   ")
 	}
 
-	screen.WriteString(url)
+	screen.WriteString(r.Url)
 
 	return screen.String()
 }
+
+func (i addonInfo) show() {
+	color := i.AddonsType.color()
+	field := func(name string, values ...string) {
+		if v := strings.Join(values, ", "); v != "" {
+			fmt.Println(brush.Paint(color, nil, name+":"), v)
+		}
+	}
+	keys := func(m map[string]*dependency) (list []string) {
+		for id, dep := range m {
+			if dep != nil && dep.Version != "" {
+				id += " " + dep.Version
+			}
+			list = append(list, id)
+		}
+		slices.Sort(list)
+		return
+	}
+
+	fmt.Println(brush.Join(
+		brush.Paint(brush.White, brush.UseColor(color), " ", strings.ToUpper(i.AddonsType.String()), " "),
+		brush.Paint(color, nil, "\t"+i.ID),
+		"\tv. ", i.Version,
+	))
+	field("Name", i.Name)
+	field("Description", i.Description)
+	field("Mod version", i.ModVersion)
+	field("Tags", i.Tags...)
+	field("Provides", i.Provides...)
+	field("Replaces", i.Replaces...)
+	field("Conflicts", keys(i.Conflicts)...)
+	if len(i.Arch) > 0 {
+		field("Arch", strings.Join(i.Arch, ", ")+" (supported: "+strconv.FormatBool(i.Supported)+")")
+	}
+	field("Remote", i.Source)
+	field("Also available", i.Alternatives...)
+	if i.Endpoint != "" {
+		field("Endpoint", i.Endpoint+" (singleton: "+strconv.FormatBool(i.Singleton)+")")
+	}
+	field("Post", string(i.Post))
+	for _, f := range i.Files {
+		field("File", f.Url)
+	}
+	extra := make([]string, 0, len(i.Extra))
+	for k, v := range i.Extra {
+		extra = append(extra, k+"="+v)
+	}
+	slices.Sort(extra)
+	field("Extra", extra...)
+
+	switch {
+	case i.Installed != nil:
+		field("Status", "installed v"+i.Installed.Version)
+	case i.Unmanaged:
+		field("Status", "installed manually")
+	default:
+		field("Status", "not installed")
+	}
+
+	if len(i.Tree) > 0 {
+		fmt.Println(brush.Paint(color, nil, "Dependencies:"))
+		showTree(i.Tree, " ")
+	}
+
+	if len(i.Changes) == 0 {
+		fmt.Println("\nAlready up to date")
+		return
+	}
+	fmt.Println("\nInstalling it would:")
+	for _, c := range i.Changes {
+		fmt.Println(" -", c)
+	}
+	fmt.Println()
+}
+
+func showTree(nodes []dependencyNode, indent string) {
+	for n, node := range nodes {
+		branch, next := "├ ", "│ "
+		if n == len(nodes)-1 {
+			branch, next = "└ ", "  "
+		}
+
+		line := node.ID
+		if node.Constraint != "" {
+			line += " " + node.Constraint
+		}
+		if node.Optional {
+			line += " (optional)"
+		}
+		switch {
+		case node.Installed != "":
+			line += " installed v" + node.Installed
+		case node.Available != "":
+			line += " available v" + node.Available
+		default:
+			line += brush.Paint(brush.Red, nil, " missing").String()
+		}
+
+		fmt.Println(indent + branch + line)
+		showTree(node.Dependencies, indent+next)
+	}
+}
+
+func (p plan) show(header string, usage diskUsage) {
+	success(header, "Dry run, nothing will be changed")
+
+	for i, line := range p.describe() {
+		var (
+			s    = p[i]
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Green), " + ")
+		)
+		switch s.Action {
+		case removeStep:
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Red), " - ")
+		case postStep:
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Yellow), " $ ")
+		}
+
+		fmt.Println(" ", sign, line)
+		if s.Endpoint != "" {
+			fmt.Println("      from", s.Endpoint)
+		}
+		if s.Path != "" {
+			fmt.Println("      at", s.Path)
+		}
+		if s.Action != postStep {
+			fmt.Println("      size", formatSize(s.Size))
+		}
+	}
+
+	fmt.Print("\nDisk usage: +", formatSize(usage.Downloaded), " -", formatSize(usage.Freed))
+	if usage.Unknown > 0 {
+		fmt.Print(" (", usage.Unknown, " of unknown size)")
+	}
+	fmt.Println()
+}
+
+func formatSize(size int64) string {
+	if size < 0 {
+		return "unknown"
+	}
+
+	const units = "KMGT"
+	if size < 1024 {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+
+	value, i := float64(size)/1024, 0
+	for ; value >= 1024 && i < len(units)-1; i++ {
+		value /= 1024
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + units[i:i+1] + "B"
+}
+
+func showGroups(groups []groupReport) {
+	if len(groups) == 0 {
+		return
+	} else if plumbing() {
+		result.Groups = append(result.Groups, groups...)
+		return
+	}
+
+	success("Groups", strconv.Itoa(len(groups))+" meta addons installed:")
+	for _, g := range groups {
+		fmt.Println(" ", meta.icon(), g.ID, brush.Paint(brush.BrightBlack, nil, strings.Join(g.Members, ", ")))
+	}
+	fmt.Println()
+}
+
+func showUntracked(untracked []addon) {
+	if len(untracked) == 0 {
+		return
+	} else if plumbing() {
+		result.Untracked = append(result.Untracked, untracked...)
+		return
+	}
+
+	warn("Untracked", strconv.Itoa(len(untracked))+" files are not managed by lxl")
+	for _, a := range untracked {
+		fmt.Println(" ", a.AddonsType.icon(), a.Path)
+	}
+	if verbosity >= normalLevel {
+		hint("\nYou can try to match them with the remotes using ")
+		command(" lxl adopt ")
+	}
+}
+
+func showProblems(problems []*problem) {
+	if len(problems) == 0 {
+		success(current.name, "No problem found")
+		return
+	}
+
+	fixable := 0
+	for _, p := range problems {
+		sign := brush.Paint(brush.BrightWhite, brush.UseColor(brush.Red), " X ")
+		switch {
+		case p.Fixed:
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Green), " v ")
+		case p.fix != nil:
+			fixable++
+		}
+		fmt.Println(" ", sign, brush.Paint(brush.Yellow, nil, p.Check), p.Subject+":", p.Message)
+	}
+
+	if fixable > 0 && verbosity >= normalLevel {
+		hint("\n", fixable, " problems can be fixed using ")
+		command(" lxl doctor --fix ")
+	}
+}
+
+func showProfiles(list []profileReport) {
+	if plumbing() {
+		result.Profiles = list
+		return
+	}
+
+	success("Found "+strconv.Itoa(len(list))+" profiles", "The active one is marked:")
+	for _, p := range list {
+		sign := "   "
+		if p.Active {
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Green), " * ").String()
+		}
+		fmt.Println(" ", sign, p.Name, brush.Paint(brush.BrightBlack, nil, "(", p.Addons, " addons)"))
+	}
+}
+
+func showIssues(issues []issue) {
+	if plumbing() {
+		result.Issues = issues
+		return
+	} else if len(issues) == 0 {
+		success(current.name, "No issue found")
+		return
+	}
+
+	for _, i := range issues {
+		sign := brush.Paint(brush.BrightWhite, brush.UseColor(brush.Red), " X ")
+		if i.Severity == warningSeverity {
+			sign = brush.Paint(brush.BrightWhite, brush.UseColor(brush.Yellow), " ! ")
+		}
+		fmt.Println(" ", sign, brush.Paint(brush.BrightBlack, nil, i.Source), brush.Paint(brush.Yellow, nil, i.Path), i.Message)
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/BurntSushi/toml"
 )
 
+// TEMP_PREFIX is used on temporary folders so they can be found when stale
+const TEMP_PREFIX = "lxl-"
+
 type skipErr struct{}
 
 func (s skipErr) Error() string {
@@ -23,19 +26,38 @@ func (s skipErr) Error() string {
 var skip = skipErr{}
 
 func get(url string) (body []byte, err error) {
+	debug("GET %s", url)
 	res, err := http.Get(url)
 	if err != nil {
+		debug("GET %s failed: %s", url, err)
 		return
 	}
 
 	body, err = io.ReadAll(res.Body)
 	res.Body.Close()
+	trace("GET %s: %s, %d bytes", url, res.Status, len(body))
 	if res.StatusCode > 299 {
 		err = fmt.Errorf("[%d] endpoint: %s, body: %s\n", res.StatusCode, url, body)
 	}
 	return
 }
 
+// headSize retrieves the size of the content at url, negative if unknown
+func headSize(url string) int64 {
+	res, err := http.Head(url)
+	if err != nil {
+		debug("HEAD %s failed: %s", url, err)
+		return -1
+	}
+	res.Body.Close()
+	trace("HEAD %s: %s, %d bytes", url, res.Status, res.ContentLength)
+
+	if res.StatusCode > 299 {
+		return -1
+	}
+	return res.ContentLength
+}
+
 func configPath(directory ...string) (dir string, err error) {
 	if dir, err = os.UserHomeDir(); err == nil {
 		dir = filepath.Join(append([]string{dir, ".config", "lite-xl"}, directory...)...)
@@ -44,8 +66,72 @@ func configPath(directory ...string) (dir string, err error) {
 	return
 }
 
+func checksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return sum(content), nil
+}
+
+// fingerprint maps each file inside path to its checksum, git folders are
+// kept as a whole
+func fingerprint(path string) (files map[string]string, err error) {
+	files = make(map[string]string)
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, errin error) (err error) {
+		switch {
+		case errin != nil:
+			return errin
+		case d.IsDir() && d.Name() == ".git":
+			files[p] = ""
+			return filepath.SkipDir
+		case !d.IsDir():
+			files[p], err = checksum(p)
+		}
+		return
+	})
+	return
+}
+
+// snapshot fingerprints the lite-xl config folder excluding lxl own files
+func snapshot(root string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() && p == filepath.Join(root, "lxl") {
+			return filepath.SkipDir
+		} else if !d.IsDir() {
+			files[p] = ""
+		}
+		return nil
+	})
+	for p := range files {
+		files[p], _ = checksum(p)
+	}
+	return files, err
+}
+
+// prune removes the empty folders from dir up to the addons type folders
+func prune(dir string) {
+	root, err := configPath()
+	if err != nil {
+		return
+	}
+
+	for ; filepath.Dir(dir) != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		trace("removed empty folder %s", dir)
+	}
+}
+
+// remove deletes a file or a folder, links are removed without following them
+// so that even dangling ones are not left behind
 func remove(path string) (err error) {
-	if _, err = os.Stat(path); err == nil {
+	if _, err = os.Lstat(path); err == nil {
 		err = os.RemoveAll(path)
 	}
 
@@ -59,19 +145,21 @@ func clone(repoEndpoint, path string) (string, error) {
 	}
 
 	if path == "" {
-		path, err = os.MkdirTemp("", name)
+		path, err = os.MkdirTemp("", TEMP_PREFIX+name)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	cmd := exec.Command("git", "clone", repo, path)
+	debug("running %s", cmd)
 	if err = cmd.Run(); err != nil {
 		return "", fmt.Errorf("Cannot clone repository %s: %w", repo, err)
 	}
 
 	if commit != "" {
 		cmd = exec.Command("git", "--git-dir="+filepath.Join(path, ".git"), "--work-tree="+path, "checkout", commit)
+		debug("running %s", cmd)
 		if err = cmd.Run(); err != nil {
 			return "", fmt.Errorf("Cannot checkout at %s: %w", commit, err)
 		}
@@ -80,6 +168,16 @@ func clone(repoEndpoint, path string) (string, error) {
 	return path, nil
 }
 
+// headCommit is the commit checked out on a cloned repository
+func headCommit(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return "", err
+	}
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
 func extract(rawrepo string) (repo, name, commit string, err error) {
 	rgx := regexp.MustCompile(`^(https?://[\w\-/\.]+/([\w\-\.]+)):?(\w+)?$`)
 	res := rgx.FindStringSubmatch(rawrepo)
@@ -185,7 +283,10 @@ func moveDirFiltered(from, to string, allow func(string, os.DirEntry) bool) erro
 					err = os.Mkdir(filepath.Join(to, strings.TrimPrefix(path, from)), 0750)
 				}
 			} else if d.IsDir() {
+				trace("skipping directory %s", path)
 				err = filepath.SkipDir
+			} else {
+				trace("skipping %s", path)
 			}
 
 			return
@@ -195,6 +296,7 @@ func moveDirFiltered(from, to string, allow func(string, os.DirEntry) bool) erro
 
 	for path := range queue {
 		tail := strings.TrimPrefix(path, from)
+		trace("moving %s to %s", path, filepath.Join(to, tail))
 		if err := os.Rename(path, filepath.Join(to, tail)); err != nil {
 			return err
 		}
@@ -204,7 +306,8 @@ func moveDirFiltered(from, to string, allow func(string, os.DirEntry) bool) erro
 }
 
 func rangeSaved(each func(addon) error) error {
-	ch, errch := make(chan []addon, len(aTypes)-1), make(chan error, 1)
+	types := installable()
+	ch, errch := make(chan []addon, len(types)), make(chan error, 1)
 
 	fn := func(t addonsType) {
 		var list []addon
@@ -245,11 +348,11 @@ func rangeSaved(each func(addon) error) error {
 		}
 	}
 
-	for i := range aTypes[:len(aTypes)-1] {
-		go fn(addonsType(i))
+	for _, t := range types {
+		go fn(t)
 	}
 
-	for i := 0; i < len(aTypes)-1; {
+	for i := 0; i < len(types); {
 		select {
 		case addons := <-ch:
 			for _, a := range addons {
@@ -271,18 +374,33 @@ func loadStatus() (err error) {
 		content []byte
 		path    string
 	)
-	if path, err = configPath("lxl", "status.toml"); err != nil {
+	if cache != nil {
+		return nil
+	} else if path, err = configPath("lxl", "status.toml"); err != nil {
 		return err
 	}
 
 	// Read /lxl/status.toml
 	if content, err = os.ReadFile(path); err == nil {
 		cache = new(lxl)
-		if err = toml.Unmarshal(content, cache); err == nil {
-			return
+		if err = toml.Unmarshal(content, cache); err != nil {
+			cache = nil
+			err = failure(errStatus, "Cannot parse %s: %s", path, err)
+		} else {
+			for _, info := range cache.Types {
+				if e := defineType(info); e != nil {
+					debug("cannot define type %s: %s", info.Name, e)
+				}
+			}
+			if !cache.TrustLevels {
+				if migrated := cache.migrateTrust(); len(migrated) > 0 {
+					warn("Trusted remotes", strings.Join(migrated, ", ")+" subscribed before trust levels are now trusted, use \"lxl distrust\" to limit them")
+				}
+				err = saveStatus()
+			}
 		}
 	} else if os.IsNotExist(err) {
-		cache = &lxl{Path: path, Remotes: []string{
+		cache = &lxl{Path: path, TrustLevels: true, Remotes: []string{
 			BASE_ENDPOINT + "lite-xl-plugins" + suffix,
 			BASE_ENDPOINT + "lite-xl-lsp-servers" + suffix,
 			BASE_ENDPOINT + "lite-xl-ide" + suffix,
@@ -295,7 +413,7 @@ func loadStatus() (err error) {
 }
 
 func saveStatus() (err error) {
-	if err = os.Mkdir(filepath.Dir(cache.Path), 0750); err != nil && !os.IsExist(err) {
+	if err = os.MkdirAll(filepath.Dir(cache.Path), 0750); err != nil {
 		return
 	}
 
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a dot separated list of numbers, pre-release and build suffixes
+// are ignored
+type version []int
+
+func parseVersion(raw string) (v version, err error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if ind := strings.IndexAny(raw, "-+"); ind >= 0 {
+		raw = raw[:ind]
+	}
+	if raw == "" {
+		return nil, fmt.Errorf("Empty version")
+	}
+
+	for _, part := range strings.Split(raw, ".") {
+		n, e := strconv.Atoi(part)
+		if e != nil || n < 0 {
+			return nil, fmt.Errorf("Malformed version %s", raw)
+		}
+		v = append(v, n)
+	}
+	return
+}
+
+func (v version) at(i int) int {
+	if i < len(v) {
+		return v[i]
+	}
+	return 0
+}
+
+// compare returns a negative number if v is older than other, a positive one
+// if it's newer and zero when they are the same
+func (v version) compare(other version) int {
+	size := len(v)
+	if len(other) > size {
+		size = len(other)
+	}
+
+	for i := 0; i < size; i++ {
+		if d := v.at(i) - other.at(i); d != 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (v version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+func compareVersions(a, b string) int {
+	va, erra := parseVersion(a)
+	vb, errb := parseVersion(b)
+	if erra != nil || errb != nil {
+		return strings.Compare(a, b)
+	}
+	return va.compare(vb)
+}
+
+type constraint struct {
+	op string
+	v  version
+}
+
+// constraints are all satisfied by a version, they are given comma separated
+// as in ">=1.2, <2", "^1.2", "~1.2.3", "1.2.*", "!=1.3" or "*". A plain or "="
+// version matches as a prefix while "==" matches that exact version
+type constraints []constraint
+
+func parseConstraints(raw string) (list constraints, err error) {
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || item == "*" || item == "latest" {
+			continue
+		}
+
+		c := constraint{op: "="}
+		for _, op := range []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(item, op) {
+				c.op, item = op, strings.TrimSpace(item[len(op):])
+				break
+			}
+		}
+		// Wildcards match the whole prefix
+		if trimmed := strings.TrimSuffix(strings.TrimSuffix(item, ".x"), ".*"); trimmed != item {
+			if c.op != "=" {
+				return nil, fmt.Errorf("Wildcards cannot be used with %s on %s", c.op, raw)
+			}
+			item = trimmed
+		}
+
+		if c.v, err = parseVersion(item); err != nil {
+			return nil, fmt.Errorf("Malformed constraint %s: %w", raw, err)
+		}
+		list = append(list, c)
+	}
+	return
+}
+
+func (c constraint) match(v version) bool {
+	switch d := v.compare(c.v); c.op {
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	case "==":
+		return d == 0
+	case "!=":
+		return !c.prefix(v)
+	case "^":
+		// Compatible with the leftmost non zero number
+		i := 0
+		for i < len(c.v)-1 && c.v[i] == 0 {
+			i++
+		}
+		return d >= 0 && constraint{v: c.v[:i+1]}.prefix(v)
+	case "~":
+		// Compatible with the given numbers but the last one
+		if len(c.v) < 2 {
+			return d >= 0 && v.at(0) == c.v[0]
+		}
+		return d >= 0 && constraint{v: c.v[:len(c.v)-1]}.prefix(v)
+	default:
+		return c.prefix(v)
+	}
+}
+
+// prefix checks if v starts with the constraint numbers, "1.2" matches "1.2.5"
+func (c constraint) prefix(v version) bool {
+	for i, n := range c.v {
+		if v.at(i) != n {
+			return false
+		}
+	}
+	return true
+}
+
+func (list constraints) match(raw string) bool {
+	v, err := parseVersion(raw)
+	if err != nil {
+		return len(list) == 0
+	}
+
+	for _, c := range list {
+		if !c.match(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestConstraintsMatch(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		want        bool
+	}{
+		{"", "1.0", true},
+		{"*", "0.1", true},
+		{"latest", "3.2.1", true},
+		{"1.2", "1.2", true},
+		{"1.2", "1.2.5", true},
+		{"1.2", "1.3", false},
+		{"=1.2", "1.2.5", true},
+		{"==1.2", "1.2.5", false},
+		{"==1.2", "1.2", true},
+		{"==1.2", "1.2.0", true},
+		{"==1.2.0", "v1.2.0", true},
+		{"==1.2.0", "1.2.0-beta", true},
+		{">=1.2, <2", "1.9.9", true},
+		{">=1.2, <2", "2.0", false},
+		{">=1.2, <2", "1.1", false},
+		{">1", "1.0", false},
+		{"<=1", "1.0.0", true},
+		{"!=1.3", "1.3.2", false},
+		{"!=1.3", "1.4", true},
+		{"^1.2", "1.2", true},
+		{"^1.2", "1.9", true},
+		{"^1.2", "1.1", false},
+		{"^1.2", "2.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3", false},
+		{"~1.2.3", "1.2.2", false},
+		{"~1", "1.9", true},
+		{"~1", "2.0", false},
+		{"1.2.*", "1.2.7", true},
+		{"1.2.x", "1.3", false},
+		{"1.*", "1.0", true},
+		{"1.0", "latest", false},
+		{"", "latest", true},
+	}
+
+	for _, tt := range tests {
+		list, err := parseConstraints(tt.constraints)
+		if err != nil {
+			t.Errorf("parseConstraints(%q) failed: %s", tt.constraints, err)
+		} else if got := list.match(tt.version); got != tt.want {
+			t.Errorf("%q match %q = %t, want %t", tt.constraints, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintsErrors(t *testing.T) {
+	for _, raw := range []string{">=1.*", "^1.x", "==1.*", ">>1", "1.a", "=", "~"} {
+		if _, err := parseConstraints(raw); err == nil {
+			t.Errorf("parseConstraints(%q) should fail", raw)
+		}
+	}
+}
+
+func TestWantedConstraints(t *testing.T) {
+	tests := []struct {
+		w       wanted
+		version string
+		want    bool
+	}{
+		{wanted{Pin: "1.2"}, "1.2", true},
+		{wanted{Pin: "1.2"}, "1.2.1", false},
+		{wanted{Version: ">=1", Pin: "1.2.0"}, "1.2", true},
+		{wanted{Version: "^2", Pin: "1.2.0"}, "1.2.0", false},
+	}
+
+	for _, tt := range tests {
+		list, err := tt.w.constraints()
+		if err != nil {
+			t.Errorf("%s constraints failed: %s", tt.w, err)
+		} else if got := list.match(tt.version); got != tt.want {
+			t.Errorf("%s match %q = %t, want %t", tt.w, tt.version, got, tt.want)
+		}
+	}
+}